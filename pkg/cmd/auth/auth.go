@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"github.com/ffalor/credit/pkg/cmd/auth/status"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdAuth represents the auth command used to inspect github authentication
+func NewCmdAuth() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth <command>",
+		Short: "Inspect github authentication",
+		Long:  "Inspect which github token credit uses for each host.",
+	}
+
+	cmd.AddCommand(status.NewCmdStatus())
+
+	return cmd
+}
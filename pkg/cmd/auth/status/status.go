@@ -0,0 +1,77 @@
+package status
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ffalor/credit/pkg/cmdutil"
+	"github.com/ffalor/credit/pkg/util/gh"
+	"github.com/spf13/cobra"
+)
+
+type StatusOptions struct {
+	cmdutil.AuthOptions
+	Out io.Writer
+}
+
+// NewCmdStatus represents the auth status command
+func NewCmdStatus() *cobra.Command {
+	opts := &StatusOptions{
+		Out: os.Stdout,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "status",
+		Short:   "Show which github token is used and its scopes",
+		Long:    "Show for each github host which source the token was found in, the user it belongs to and its scopes.",
+		Example: "$ credit auth status --hostname github.com --hostname github.example.com",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.ResolveHostnames()
+
+			return runStatus(cmd, opts)
+		},
+	}
+
+	cmdutil.AddAuthFlags(cmd, &opts.AuthOptions)
+
+	return cmd
+}
+
+func runStatus(cmd *cobra.Command, opts *StatusOptions) error {
+	failed := false
+
+	for _, hostname := range opts.Hostnames {
+		fmt.Fprintln(opts.Out, hostname)
+
+		token, err := opts.ResolveToken(cmd.Context(), hostname, false)
+		if err != nil {
+			failed = true
+			fmt.Fprintf(opts.Out, "  X %s\n", err)
+			continue
+		}
+
+		info, err := gh.NewGh(token.Value, hostname).TokenInfo(cmd.Context())
+		if err != nil {
+			failed = true
+			fmt.Fprintf(opts.Out, "  X Token from %s could not be verified: %s\n", token.Source, err)
+			continue
+		}
+
+		scopes := "none reported (fine-grained tokens do not report scopes)"
+		if len(info.Scopes) > 0 {
+			scopes = strings.Join(info.Scopes, ", ")
+		}
+
+		fmt.Fprintf(opts.Out, "  ✓ Logged in as %s (token from %s)\n", info.Login, token.Source)
+		fmt.Fprintf(opts.Out, "  Token scopes: %s\n", scopes)
+	}
+
+	if failed {
+		return fmt.Errorf("not authenticated with every host")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ffalor/credit/pkg/cmdutil"
+	jiraapi "github.com/ffalor/credit/pkg/util/jira"
+	"github.com/ffalor/credit/pkg/util/record"
+	"github.com/ffalor/credit/pkg/util/state"
+	"github.com/spf13/cobra"
+)
+
+type JiraOptions struct {
+	cmdutil.FetchOptions
+	cmdutil.JiraUserOptions
+	Project string
+	DryRun  bool
+	Out     io.Writer
+}
+
+// NewCmdPushJira represents the push jira command
+func NewCmdPushJira() *cobra.Command {
+	opts := &JiraOptions{
+		Out: os.Stdout,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "jira [user...] -f <YYYY-MM-DD|range>",
+		Short: "Create Jira issues for github credit through the Jira REST API",
+		Long: `Create Jira issues for all github issues from a start date through the Jira REST API.
+
+The Jira site and credentials are read from the config file or the JIRA_URL, JIRA_EMAIL
+and JIRA_API_TOKEN environment variables. Leave JIRA_EMAIL empty to authenticate with a
+personal access token.`,
+		Example: "$ credit push jira ffalor -f 2020-01-01 --project CRED",
+		Args:    cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Resolve(cmd.Context(), args); err != nil {
+				return err
+			}
+
+			jiraConfig := &opts.Config.Jira
+			if opts.Project != "" {
+				jiraConfig.Project = opts.Project
+			}
+			jiraConfig.ApplyEnv()
+
+			if jiraConfig.Project == "" {
+				return fmt.Errorf("a Jira project is required, use --project or set jira.project in the config file")
+			}
+			if !opts.DryRun && (jiraConfig.URL == "" || jiraConfig.Token == "") {
+				return fmt.Errorf("a Jira url and token are required, set JIRA_URL and JIRA_API_TOKEN or jira.url and jira.token in the config file")
+			}
+
+			return runJira(cmd, opts)
+		},
+	}
+
+	cmdutil.AddFetchFlags(cmd, &opts.FetchOptions)
+	cmdutil.AddStateFlags(cmd, &opts.FetchOptions)
+	cmdutil.AddJiraUserFlags(cmd, &opts.JiraUserOptions)
+	cmd.Flags().StringVarP(&opts.Project, "project", "p", "", "Jira project key to create issues in (default jira.project from the config file)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print the issue/bulk request payloads instead of creating issues")
+
+	return cmd
+}
+
+func runJira(cmd *cobra.Command, opts *JiraOptions) error {
+	jiraConfig := opts.Config.Jira
+
+	// Get all merged PRs, issues and reviews that have not been exported yet
+	credit, err := opts.Fetch(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := opts.MapJiraUsers(cmd.Context(), &jiraConfig, credit.Users()); err != nil {
+		return err
+	}
+
+	records := record.Build(opts.User, credit)
+	record.Estimate(records, jiraConfig.StoryPoints)
+
+	issueUpdates := make([]jiraapi.IssueUpdate, len(records))
+	for i, r := range records {
+		issueUpdates[i], err = jiraapi.NewIssueUpdate(r, jiraConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.DryRun {
+		encoder := json.NewEncoder(opts.Out)
+		encoder.SetIndent("", "  ")
+		for _, request := range jiraapi.BulkRequests(issueUpdates) {
+			if err := encoder.Encode(request); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	client := jiraapi.NewClient(jiraConfig.URL, jiraConfig.Email, jiraConfig.Token, jiraConfig.APIVersion)
+	created, failed, err := client.BulkCreate(cmd.Context(), issueUpdates)
+
+	// report and remember what was created even when a request failed part way through
+	now := time.Now()
+	failures := 0
+	tw := tabwriter.NewWriter(opts.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "GITHUB ID\tJIRA KEY\tSUMMARY")
+	for i, r := range records {
+		switch {
+		case created[i] != nil:
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Id, created[i].Key, r.Title)
+			opts.State.Mark(r.Id, state.Entry{ExportedAt: now, Target: "jira", JiraKey: created[i].Key})
+		case failed[i] != nil:
+			failures++
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Id, "error: "+failed[i].Error(), r.Title)
+		}
+	}
+	tw.Flush()
+
+	if saveErr := opts.State.Save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d issues could not be created in Jira", failures, len(records))
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package push
+
+import (
+	"github.com/ffalor/credit/pkg/cmd/push/jira"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPush represents the push command used to send credit to an issue tracker
+func NewCmdPush() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "push <tracker>",
+		Short: "Create issues for github credit directly in an issue tracker",
+		Long:  "Create issues for all github issues from a start date directly in an issue tracker instead of exporting a csv file.",
+	}
+
+	cmd.AddCommand(jira.NewCmdPushJira())
+
+	return cmd
+}
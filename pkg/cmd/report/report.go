@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ffalor/credit/pkg/cmdutil"
+	"github.com/ffalor/credit/pkg/util/output"
+	"github.com/ffalor/credit/pkg/util/report"
+	"github.com/spf13/cobra"
+)
+
+type ReportOptions struct {
+	cmdutil.FetchOptions
+	Format       string
+	TemplatePath string
+	Summary      bool
+	Output       string
+	Force        bool
+}
+
+// NewCmdReport represents the report command
+func NewCmdReport() *cobra.Command {
+	opts := &ReportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "report [user...] -f <YYYY-MM-DD|range>",
+		Short: "Render github credit into a brag document",
+		Long: `Render merged PRs and closed issues into a brag document grouped by repository and month.
+
+The document is rendered from a Go text/template, use --template to customise headings and layout.`,
+		Example: "$ credit report ffalor -f H1-2025 --summary -o brag.md",
+		Args:    cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Resolve(cmd.Context(), args); err != nil {
+				return err
+			}
+
+			if !opts.Force && output.Exists(opts.Output) {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", opts.Output)
+			}
+
+			return runReport(cmd, opts)
+		},
+	}
+
+	cmdutil.AddFetchFlags(cmd, &opts.FetchOptions)
+	cmd.Flags().StringVar(&opts.Format, "format", "markdown", "Report format: markdown")
+	cmd.Flags().StringVar(&opts.TemplatePath, "template", "", "Path to a Go text/template used instead of the built in one for --format")
+	cmd.Flags().BoolVar(&opts.Summary, "summary", false, "Add a summary line to every section")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", output.Stdout, "File to write the report to, - for stdout")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite the output file if it already exists")
+
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, opts *ReportOptions) error {
+	tmpl, err := report.Template(opts.Format, opts.TemplatePath)
+	if err != nil {
+		return err
+	}
+
+	credit, err := opts.Fetch(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	data := report.NewData(opts.User, opts.Range, credit)
+	data.ShowSummary = opts.Summary
+
+	return output.WriteFile(opts.Output, opts.Force, func(out io.Writer) error {
+		if err := report.Render(out, tmpl, data); err != nil {
+			return fmt.Errorf("could not render report: %w", err)
+		}
+		return nil
+	})
+}
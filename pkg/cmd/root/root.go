@@ -4,103 +4,221 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package root
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
 	"time"
 
-	"github.com/AlecAivazis/survey/v2"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ffalor/credit/pkg/cmd/auth"
+	"github.com/ffalor/credit/pkg/cmd/push"
+	reportCmd "github.com/ffalor/credit/pkg/cmd/report"
+	"github.com/ffalor/credit/pkg/cmdutil"
 	"github.com/ffalor/credit/pkg/util/csvwriter"
-	"github.com/ffalor/credit/pkg/util/gh"
+	"github.com/ffalor/credit/pkg/util/jsonwriter"
+	"github.com/ffalor/credit/pkg/util/output"
+	"github.com/ffalor/credit/pkg/util/report"
+	"github.com/ffalor/credit/pkg/util/state"
 	"github.com/ffalor/credit/pkg/util/tui"
+	"github.com/ffalor/credit/pkg/util/types"
 	"github.com/spf13/cobra"
 )
 
 type RootOptions struct {
-	gh       *gh.Gh
-	FromDate string
-	User     string
+	cmdutil.FetchOptions
+	cmdutil.JiraUserOptions
+	Format       string
+	TemplatePath string
+	Output       string
+	Force        bool
+	// In and ErrOut are used by the selection TUI and for status messages, keeping stdout free
+	// for an export streamed to it
+	In       io.Reader
+	ErrOut   io.Writer
+	template *template.Template
+}
+
+// writer exports merged PRs, issues and reviews in one of the output formats
+type writer interface {
+	Write(out io.Writer, user string, credit types.Credit) error
 }
 
 // NewCmdRoot represents the base command when called without any subcommands
 func NewCmdRoot() *cobra.Command {
-	opts := &RootOptions{}
+	opts := &RootOptions{
+		In:     os.Stdin,
+		ErrOut: os.Stderr,
+	}
 
 	cmd := &cobra.Command{
-		Use:     "credit [user] -f <YYYY-MM-DD>",
+		Use:     "credit [user...] -f <YYYY-MM-DD|range>",
 		Short:   "Export all github issues into a csv file for Jira import",
-		Long:    "Export all github issues from a start date into a csv file for Jira import.",
-		Example: "$ credit ffalor -f 2020-01-01",
-		Args:    cobra.MaximumNArgs(1),
+		Long:    "Export all github issues from a start date or within a date range into a csv file for Jira import.",
+		Example: "$ credit ffalor -f 2020-01-01\n$ credit ffalor -f 2024-01-01 -t 2024-03-31\n$ credit ffalor -f last-quarter\n$ GH_TOKEN=... credit ffalor -f last-month --no-tui\n$ credit --team acme/platform -f last-sprint",
+		Args:    cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if opts.FromDate != "" && !validDate(opts.FromDate) {
-				return fmt.Errorf("invalid date format for --from, please use YYYY-MM-DD")
-			} else if opts.FromDate == "" {
-				opts.FromDate = time.Now().AddDate(0, 0, -90).Format("2006-01-02")
+			switch opts.Format {
+			case "csv", "json", "jsonl":
+			default:
+				return fmt.Errorf("invalid --format %q, please use csv, json or jsonl", opts.Format)
 			}
 
-			var user string
-
-			if len(args) == 0 {
-				prompt := &survey.Input{
-					Message: "Please enter a user to export issues for",
+			if opts.TemplatePath != "" {
+				tmpl, err := report.Template("", opts.TemplatePath)
+				if err != nil {
+					return fmt.Errorf("invalid --template: %w", err)
 				}
-				survey.AskOne(prompt, &user)
-			} else {
-				user = args[0]
+				opts.template = tmpl
 			}
 
-			githubToken, ok := os.LookupEnv("GITHUB_TOKEN")
+			if err := opts.Resolve(cmd.Context(), args); err != nil {
+				return err
+			}
 
-			if !ok {
-				prompt := &survey.Password{
-					Message: "Please enter your github token",
+			// fail before fetching and reviewing rather than when writing the export
+			if opts.Output == "" {
+				opts.Output = output.DefaultName(opts.User, opts.Range, opts.extension())
+			}
+			for _, path := range []string{opts.Output, opts.importerConfigPath()} {
+				if path != "" && !opts.Force && output.Exists(path) {
+					return fmt.Errorf("%s already exists, use --force to overwrite it", path)
 				}
-				survey.AskOne(prompt, &githubToken)
 			}
 
-			gh := gh.NewGh(githubToken)
-
-			opts.gh = gh
-			opts.User = user
-
-			return runRoot(opts)
+			return runRoot(cmd, opts)
 		},
 	}
 
-	cmd.Flags().StringVarP(&opts.FromDate, "from", "f", "", "Start date for issues to export (YYYY-MM-DD) (default 90 days ago")
+	cmdutil.AddFetchFlags(cmd, &opts.FetchOptions)
+	cmdutil.AddStateFlags(cmd, &opts.FetchOptions)
+	cmdutil.AddJiraUserFlags(cmd, &opts.JiraUserOptions)
+	cmd.Flags().StringVar(&opts.Format, "format", "csv", "Export format: csv, json or jsonl")
+	cmd.Flags().StringVar(&opts.TemplatePath, "template", "", "Path to a Go text/template to render the export with instead of --format")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "File to export to, - for stdout (default credit-<user>-<from>_<to>.<format>)")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite the output file if it already exists")
+
+	cmd.AddCommand(auth.NewCmdAuth())
+	cmd.AddCommand(push.NewCmdPush())
+	cmd.AddCommand(reportCmd.NewCmdReport())
 
 	return cmd
 }
 
-func runRoot(opts *RootOptions) error {
+func runRoot(cmd *cobra.Command, opts *RootOptions) error {
 
-	// Get all merged PRs and issues
-	allMergedPrs, allIssues, err := opts.gh.GetIssues(opts.User, opts.FromDate)
+	// Get all merged PRs, issues and reviews that have not been exported yet
+	credit, err := opts.Fetch(cmd.Context())
 	if err != nil {
 		return err
 	}
 
-	// // Write to csv file issues.csv
-	csvwriter := csvwriter.NewWriter()
-	csvwriter.Write(opts.User, allMergedPrs, allIssues)
+	return opts.export(cmd.Context(), credit, opts.Interactive())
+}
+
+// export writes the credit to the output, letting the user review it in the selection TUI first
+// when interactive
+func (opts *RootOptions) export(ctx context.Context, credit types.Credit, interactive bool) error {
+	if credit.Len() == 0 {
+		fmt.Fprintln(opts.ErrOut, "No new issues to export")
+		return nil
+	}
+
+	// Without a terminal everything is exported as is
+	selected := credit
+
+	if interactive {
+		model, err := tui.InitialModel(credit)
+		if err != nil {
+			return err
+		}
+
+		// the TUI is drawn on stderr, an export to stdout may be piped elsewhere
+		p := tea.NewProgram(model, tea.WithInput(opts.In), tea.WithOutput(opts.ErrOut))
+		finalModel, err := p.Run()
+		if err != nil {
+			fmt.Fprintf(opts.ErrOut, "Alas, there's been an error: %v", err)
+			os.Exit(1)
+		}
+
+		var ok bool
+		selected, ok = tui.Selection(finalModel)
+		if !ok {
+			fmt.Fprintln(opts.ErrOut, "Selection was not submitted, nothing exported")
+			return nil
+		}
+	}
+
+	// Write the submitted selection to the output file
+	var w writer
+	switch {
+	case opts.template != nil:
+		w = report.NewWriter(opts.template, opts.Range)
+	case opts.Format == "json":
+		w = jsonwriter.NewWriter(false)
+	case opts.Format == "jsonl":
+		w = jsonwriter.NewWriter(true)
+	default:
+		// the csv is imported into Jira, which needs Jira users rather than GitHub logins
+		opts.Config.Jira.ApplyEnv()
+		if err := opts.MapJiraUsers(ctx, &opts.Config.Jira, selected.Users()); err != nil {
+			return err
+		}
+		w = csvwriter.NewWriter(opts.Config.Jira)
+	}
 
-	model, err := tui.InitialModel(allMergedPrs, allIssues)
+	err := output.WriteFile(opts.Output, opts.Force, func(out io.Writer) error {
+		return w.Write(out, opts.User, selected)
+	})
 	if err != nil {
 		return err
 	}
 
-	p := tea.NewProgram(model)
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Alas, there's been an error: %v", err)
-		os.Exit(1)
+	// The Jira importer configuration for a csv export is written next to it
+	if csvWriter, ok := w.(*csvwriter.Writer); ok && opts.importerConfigPath() != "" {
+		if err := output.WriteFile(opts.importerConfigPath(), opts.Force, csvWriter.WriteImporterConfig); err != nil {
+			return err
+		}
+	}
+
+	if opts.Output != output.Stdout {
+		fmt.Fprintf(opts.ErrOut, "Exported %d issues to %s\n", selected.Len(), opts.Output)
+	}
+
+	// Remember what was exported to Jira so the next run only shows new issues, json and template
+	// exports feed other tools and reports and leave the state as is
+	if _, ok := w.(*csvwriter.Writer); !ok {
+		return nil
+	}
+	opts.State.MarkCredit(selected, state.Entry{ExportedAt: time.Now(), Target: "csv"})
+
+	return opts.State.Save()
+}
+
+// importerConfigPath returns the path of the Jira importer configuration written next to a csv
+// export, empty when the export is not a csv file
+func (opts *RootOptions) importerConfigPath() string {
+	if opts.TemplatePath != "" || opts.Format != "csv" || opts.Output == output.Stdout {
+		return ""
 	}
 
-	return nil
+	return strings.TrimSuffix(opts.Output, filepath.Ext(opts.Output)) + ".import-config.json"
 }
 
-// validDate checks if a date is in the format YYYY-MM-DD
-func validDate(date string) bool {
-	_, err := time.Parse("2006-01-02", date)
-	return err == nil
+// extension returns the file extension of the export, taken from the template file name for
+// templates e.g. wiki.md.tmpl exports .md files
+func (opts *RootOptions) extension() string {
+	if opts.TemplatePath == "" {
+		return opts.Format
+	}
+
+	ext := filepath.Ext(strings.TrimSuffix(filepath.Base(opts.TemplatePath), ".tmpl"))
+	if ext == "" {
+		return "txt"
+	}
+
+	return strings.TrimPrefix(ext, ".")
 }
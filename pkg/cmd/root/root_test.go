@@ -0,0 +1,168 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/output"
+	"github.com/ffalor/credit/pkg/util/state"
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// captureStdout returns everything fn writes to stdout
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	read := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		read <- data
+	}()
+
+	err = fn()
+	w.Close()
+	data := <-read
+
+	if err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestExportToStdout(t *testing.T) {
+	credit := types.NewCredit()
+	credit.MergedPrs["PR_1"] = types.MergedPr{Id: "PR_1", Title: "Fix the thing", User: "octocat"}
+	credit.Issues["I_1"] = types.Issue{Id: "I_1", Title: "The thing is broken", User: "octocat"}
+
+	tests := []struct {
+		name        string
+		interactive bool
+		// input are the keys pressed in the selection TUI, select the first item and submit
+		input      string
+		wantIssue  bool
+		wantStderr string
+	}{
+		{name: "non-interactive", wantIssue: true},
+		// the TUI is drawn on stderr
+		{name: "selection TUI", interactive: true, input: "\r\x13", wantStderr: "Exporting selected issues"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var stderr bytes.Buffer
+			opts := &RootOptions{
+				Format: "json",
+				Output: output.Stdout,
+				In:     strings.NewReader(tt.input),
+				ErrOut: &stderr,
+			}
+			opts.User = "octocat"
+			opts.State = store
+
+			stdout := captureStdout(t, func() error {
+				return opts.export(context.Background(), credit, tt.interactive)
+			})
+
+			var export map[string]interface{}
+			if err := json.Unmarshal([]byte(stdout), &export); err != nil {
+				t.Fatalf("stdout is not only the json export: %v\n%s", err, stdout)
+			}
+			if !strings.Contains(stdout, "Fix the thing") {
+				t.Errorf("stdout = %s, want the exported PR", stdout)
+			}
+			if got := strings.Contains(stdout, "The thing is broken"); got != tt.wantIssue {
+				t.Errorf("issue exported = %v, want %v", got, tt.wantIssue)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestExportNothingToStdout(t *testing.T) {
+	var stderr bytes.Buffer
+	opts := &RootOptions{Format: "json", Output: output.Stdout, ErrOut: &stderr}
+
+	stdout := captureStdout(t, func() error {
+		return opts.export(context.Background(), types.NewCredit(), true)
+	})
+
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing", stdout)
+	}
+	if !strings.Contains(stderr.String(), "No new issues to export") {
+		t.Errorf("stderr = %q, want the status message", stderr.String())
+	}
+}
+
+func TestExportMarksJiraExports(t *testing.T) {
+	credit := types.NewCredit()
+	credit.MergedPrs["PR_1"] = types.MergedPr{Id: "PR_1", Title: "Fix the thing", User: "octocat"}
+
+	tests := []struct {
+		format     string
+		wantMarked bool
+	}{
+		{format: "csv", wantMarked: true},
+		{format: "json"},
+		{format: "jsonl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(configPath, []byte("{}"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			store, err := state.Load(filepath.Join(dir, "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := &RootOptions{
+				Format: tt.format,
+				Output: filepath.Join(dir, "export."+tt.format),
+				ErrOut: io.Discard,
+			}
+			opts.User = "octocat"
+			opts.Config = cfg
+			opts.State = store
+
+			if err := opts.export(context.Background(), credit, false); err != nil {
+				t.Fatalf("export() error = %v", err)
+			}
+
+			// json exports feed other tools and must not hide items from the next Jira export
+			if got := store.Has("PR_1"); got != tt.wantMarked {
+				t.Errorf("marked as exported = %v, want %v", got, tt.wantMarked)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ffalor/credit/pkg/util/auth"
+	"github.com/ffalor/credit/pkg/util/gh"
+	"github.com/spf13/cobra"
+)
+
+// AuthOptions holds the flags selecting the github hosts to use and how to authenticate with them
+type AuthOptions struct {
+	Hostnames []string
+	Token     string
+	TokenFile string
+}
+
+// AddAuthFlags registers the AuthOptions flags on cmd
+func AddAuthFlags(cmd *cobra.Command, opts *AuthOptions) {
+	cmd.Flags().StringSliceVar(&opts.Hostnames, "hostname", nil, "GitHub hostname to fetch from, repeat to fetch from several hosts e.g. github.com and a GitHub Enterprise Server (default $GH_HOST or github.com)")
+	cmd.Flags().StringVar(&opts.Token, "token", "", "GitHub token to use for every host (default GH_TOKEN, GITHUB_TOKEN, gh CLI config, git credential helper or token file)")
+	cmd.Flags().StringVar(&opts.TokenFile, "token-file", "", "File holding a github token or hostname=token lines (default $XDG_CONFIG_HOME/credit/token)")
+}
+
+// ResolveHostnames defaults the hostnames to $GH_HOST or github.com when none were given
+func (opts *AuthOptions) ResolveHostnames() {
+	if len(opts.Hostnames) > 0 {
+		return
+	}
+
+	if host, ok := os.LookupEnv("GH_HOST"); ok && host != "" {
+		opts.Hostnames = []string{host}
+	} else {
+		opts.Hostnames = []string{gh.DefaultHost}
+	}
+}
+
+// ResolveToken finds the token for hostname, prompting for one when no source has it and
+// prompt is true
+func (opts *AuthOptions) ResolveToken(ctx context.Context, hostname string, prompt bool) (auth.Token, error) {
+	token, err := auth.Resolve(ctx, hostname, auth.Options{
+		Token:     opts.Token,
+		TokenFile: opts.TokenFile,
+	})
+	if !errors.Is(err, auth.ErrNoToken) || !prompt {
+		return token, err
+	}
+
+	message := "Please enter your github token"
+	if gh.IsEnterprise(hostname) {
+		message = fmt.Sprintf("Please enter your github token for %s", hostname)
+	}
+
+	var value string
+	survey.AskOne(&survey.Password{Message: message}, &value)
+
+	return auth.Token{Value: value, Source: "prompt"}, nil
+}
@@ -0,0 +1,429 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/filter"
+	"github.com/ffalor/credit/pkg/util/gh"
+	"github.com/ffalor/credit/pkg/util/state"
+	"github.com/ffalor/credit/pkg/util/tui"
+	"github.com/ffalor/credit/pkg/util/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// FetchOptions holds the flags shared by every command that fetches credit from GitHub
+type FetchOptions struct {
+	AuthOptions
+	Clients    []*gh.Gh
+	Config     *config.Config
+	State      *state.Store
+	ConfigPath string
+	StatePath  string
+	FromDate   string
+	ToDate     string
+	Range      daterange.Range
+	// User names the exported users, the login of a single user or the team or list of logins
+	User string
+	// Users are the logins to fetch credit for
+	Users           []string
+	UsersFile       string
+	Team            string
+	Parallel        int
+	IncludeExported bool
+	ResetState      bool
+	NoTUI           bool
+	Reviews         bool
+	// IncludeNotPlanned includes issues closed as not planned
+	IncludeNotPlanned bool
+	IssueAttribution  []string
+	// Fold is one of types.FoldModes, empty to keep PRs and their closed issues apart
+	Fold string
+	// Commits is one of gh.CommitGroupings, empty to leave out commits to default branches
+	Commits     string
+	Source      string
+	Filter      filter.Filter
+	PrivateOnly bool
+	PublicOnly  bool
+	// useState is set by AddStateFlags, commands without it ignore the export state
+	useState bool
+}
+
+// AddFetchFlags registers the FetchOptions flags on cmd
+func AddFetchFlags(cmd *cobra.Command, opts *FetchOptions) {
+	cmd.Flags().StringVarP(&opts.FromDate, "from", "f", "", "Start date (YYYY-MM-DD), range (YYYY-MM-DD..YYYY-MM-DD) or named range (e.g. last-quarter, H1-2025) for issues to export (default 90 days ago)")
+	cmd.Flags().StringVarP(&opts.ToDate, "to", "t", "", "End date for issues to export (YYYY-MM-DD) (default today)")
+	cmd.Flags().StringVarP(&opts.ConfigPath, "config", "c", "", "Path to the config file (default $XDG_CONFIG_HOME/credit/config.json)")
+	cmd.Flags().StringVar(&opts.UsersFile, "users-file", "", "File with one user per line to fetch credit for, in addition to the user arguments")
+	cmd.Flags().StringVar(&opts.Team, "team", "", "Fetch credit for every member of a GitHub team (org/team), resolved on the first --hostname")
+	cmd.Flags().IntVar(&opts.Parallel, "parallel", 4, "Number of users to fetch at once")
+	AddAuthFlags(cmd, &opts.AuthOptions)
+	cmd.Flags().BoolVar(&opts.Reviews, "reviews", false, "Include reviews given on other people's PRs")
+	cmd.Flags().BoolVar(&opts.IncludeNotPlanned, "include-not-planned", false, "Include issues closed as not planned")
+	cmd.Flags().StringVar(&opts.Commits, "commits", "", "Include commits pushed to the default branch of the --org and --repo repositories outside of merged PRs, grouped by day or repo. Replaces the commits of --source contributions")
+	cmd.Flags().StringVar(&opts.Fold, "fold", "", "Combine merged PRs with the issues they closed into one item: pr folds issues into their PR, issue folds PRs into their issue")
+	cmd.Flags().StringSliceVar(&opts.IssueAttribution, "issue-attribution", gh.DefaultAttribution, "How closed issues are credited, in order of preference: "+strings.Join(gh.Attributions, ", "))
+	cmd.Flags().StringVar(&opts.Source, "source", gh.SourceSearch, "Where to find credit: search, or contributions to also include commits and created repositories. Contributions misses PRs and issues opened over a year before --from")
+	cmd.Flags().StringSliceVar(&opts.Filter.Orgs, "org", nil, "Only include repositories owned by the organization, repeat for several")
+	cmd.Flags().StringArrayVar(&opts.Filter.Repos, "repo", nil, "Only include repositories matching owner/name, globs allowed e.g. ffalor/*, repeat for several")
+	cmd.Flags().StringArrayVar(&opts.Filter.ExcludeRepos, "exclude-repo", nil, "Leave out repositories matching owner/name, globs allowed, repeat for several")
+	cmd.Flags().BoolVar(&opts.PrivateOnly, "private-only", false, "Only include private repositories")
+	cmd.Flags().BoolVar(&opts.PublicOnly, "public-only", false, "Only include public repositories")
+	cmd.MarkFlagsMutuallyExclusive("private-only", "public-only")
+	cmd.Flags().BoolVar(&opts.NoTUI, "no-tui", false, "Run without prompts or the TUI, exporting everything (default when stdin is not a terminal)")
+	cmd.Flags().BoolVarP(&opts.NoTUI, "yes", "y", false, "Alias for --no-tui")
+}
+
+// AddStateFlags registers the flags controlling the export state on cmd, leaving out issues that
+// were exported by a previous run
+func AddStateFlags(cmd *cobra.Command, opts *FetchOptions) {
+	opts.useState = true
+
+	cmd.Flags().StringVar(&opts.StatePath, "state", "", "Path to the file tracking issues exported to Jira (default $XDG_DATA_HOME/credit/state.json)")
+	cmd.Flags().BoolVar(&opts.IncludeExported, "include-exported", false, "Include issues that were exported to Jira by a previous run")
+	cmd.Flags().BoolVar(&opts.ResetState, "reset-state", false, "Forget all previously exported issues before running")
+}
+
+// Resolve validates the flags, loads the config file, prompts for a missing user or
+// github token and creates the github client
+func (opts *FetchOptions) Resolve(ctx context.Context, args []string) error {
+	if opts.FromDate == "" {
+		opts.FromDate = time.Now().AddDate(0, 0, -90).Format(daterange.DateFormat)
+	}
+
+	dateRange, err := daterange.Parse(opts.FromDate, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+
+	if opts.ToDate != "" {
+		if !dateRange.To.IsZero() {
+			return fmt.Errorf("--to can not be combined with a --from range")
+		}
+
+		dateRange.To, err = time.Parse(daterange.DateFormat, opts.ToDate)
+		if err != nil {
+			return fmt.Errorf("invalid date format for --to, please use YYYY-MM-DD")
+		}
+
+		if dateRange.To.Before(dateRange.From) {
+			return fmt.Errorf("--to must not be before --from")
+		}
+	}
+
+	opts.Range = dateRange
+
+	switch opts.Source {
+	case gh.SourceSearch, gh.SourceContributions:
+	default:
+		return fmt.Errorf("invalid --source %q, please use %s", opts.Source, strings.Join(gh.Sources, " or "))
+	}
+
+	switch opts.Fold {
+	case "", types.FoldIntoPr, types.FoldIntoIssue:
+	default:
+		return fmt.Errorf("invalid --fold %q, please use %s", opts.Fold, strings.Join(types.FoldModes, " or "))
+	}
+
+	switch opts.Commits {
+	case "", gh.CommitsByDay, gh.CommitsByRepo:
+	default:
+		return fmt.Errorf("invalid --commits %q, please use %s", opts.Commits, strings.Join(gh.CommitGroupings, " or "))
+	}
+
+	if opts.Commits != "" && len(opts.Filter.Orgs) == 0 && len(opts.Filter.Repos) == 0 {
+		return fmt.Errorf("--commits requires --org or --repo to select the repositories")
+	}
+
+	for _, strategy := range opts.IssueAttribution {
+		switch strategy {
+		case gh.AttributionAuthor, gh.AttributionAssignee, gh.AttributionCloser, gh.AttributionPrAuthor:
+		default:
+			return fmt.Errorf("invalid --issue-attribution %q, please use %s", strategy, strings.Join(gh.Attributions, ", "))
+		}
+	}
+
+	switch {
+	case opts.PrivateOnly:
+		opts.Filter.Visibility = filter.Private
+	case opts.PublicOnly:
+		opts.Filter.Visibility = filter.Public
+	}
+
+	if err := opts.Filter.Validate(); err != nil {
+		return err
+	}
+
+	if opts.Parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	users := append([]string{}, args...)
+
+	if opts.UsersFile != "" {
+		fileUsers, err := readUsersFile(opts.UsersFile)
+		if err != nil {
+			return fmt.Errorf("invalid --users-file: %w", err)
+		}
+		users = append(users, fileUsers...)
+	}
+
+	var teamOrg, teamSlug string
+	if opts.Team != "" {
+		var ok bool
+		teamOrg, teamSlug, ok = strings.Cut(opts.Team, "/")
+		if !ok || teamOrg == "" || teamSlug == "" {
+			return fmt.Errorf("invalid --team %q, please use org/team", opts.Team)
+		}
+	}
+
+	if len(users) == 0 && opts.Team == "" && !opts.Interactive() {
+		return fmt.Errorf("a user is required when running non-interactively")
+	} else if len(users) == 0 && opts.Team == "" {
+		var user string
+		prompt := &survey.Input{
+			Message: "Please enter a user to export issues for",
+		}
+		survey.AskOne(prompt, &user)
+		users = append(users, user)
+	}
+
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	if opts.useState {
+		store, err := state.Load(opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		if opts.ResetState {
+			store.Reset()
+			if err := store.Save(); err != nil {
+				return err
+			}
+		}
+
+		opts.State = store
+	}
+
+	opts.ResolveHostnames()
+
+	opts.Clients = nil
+	for _, hostname := range opts.Hostnames {
+		token, err := opts.ResolveToken(ctx, hostname, opts.Interactive())
+		if err != nil {
+			return fmt.Errorf("%w, set GH_TOKEN or use --token when running non-interactively", err)
+		}
+		opts.Clients = append(opts.Clients, gh.NewGh(token.Value, hostname))
+	}
+
+	if opts.Team != "" {
+		members, err := opts.Clients[0].TeamMembers(ctx, teamOrg, teamSlug)
+		if err != nil {
+			return fmt.Errorf("could not resolve --team: %w", err)
+		}
+		users = append(users, members...)
+	}
+
+	opts.Config = cfg
+	opts.Users = uniqueUsers(users)
+
+	switch {
+	case len(opts.Users) == 0:
+		return fmt.Errorf("no users to fetch credit for")
+	case len(opts.Users) == 1:
+		opts.User = opts.Users[0]
+	case opts.Team != "" && len(args) == 0 && opts.UsersFile == "":
+		opts.User = teamOrg + "-" + teamSlug
+	default:
+		opts.User = strings.Join(opts.Users, ",")
+	}
+
+	return nil
+}
+
+// Fetch returns the credit for the users, fetching up to --parallel users at once. With
+// AddStateFlags items exported by a previous run are left out unless --include-exported is set.
+// PRs and their closed issues are folded together as set by --fold. Progress is shown in a
+// spinner while fetching, warnings about left out results are printed once the fetch is done.
+func (opts *FetchOptions) Fetch(ctx context.Context) (types.Credit, error) {
+	credit := types.NewCredit()
+
+	type job struct {
+		client *gh.Gh
+		user   string
+		credit types.Credit
+		err    error
+	}
+
+	var jobs []*job
+	var warnings []string
+	for _, user := range opts.Users {
+		for _, client := range opts.Clients {
+			jobs = append(jobs, &job{client: client, user: user})
+		}
+	}
+
+	fetch := func(ctx context.Context, status func(string)) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var mu sync.Mutex
+		report := func(prefix []string, progress gh.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			prefixed := func(message string) string {
+				if len(prefix) == 0 {
+					return message
+				}
+				return fmt.Sprintf("%s: %s", strings.Join(prefix, " "), message)
+			}
+
+			if progress.Warning != "" {
+				warnings = append(warnings, prefixed(progress.Warning))
+				return
+			}
+
+			progress.Message = prefixed(progress.Message)
+			status(progressStatus(progress))
+		}
+
+		// retries are reported by the client the copies were made from
+		for _, client := range opts.Clients {
+			client := client
+			client.OnProgress = func(progress gh.Progress) {
+				report([]string{client.Host}, progress)
+			}
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, opts.Parallel)
+
+		for _, j := range jobs {
+			j := j
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					j.err = ctx.Err()
+					return
+				}
+
+				var prefix []string
+				if len(opts.Clients) > 1 {
+					prefix = append(prefix, j.client.Host)
+				}
+				if len(opts.Users) > 1 {
+					prefix = append(prefix, j.user)
+				}
+
+				client := j.client.Copy()
+				client.OnProgress = func(progress gh.Progress) {
+					report(prefix, progress)
+				}
+
+				j.credit, j.err = client.GetIssues(ctx, gh.Options{
+					User:              j.user,
+					Range:             opts.Range,
+					Source:            opts.Source,
+					Filter:            opts.Filter,
+					Reviews:           opts.Reviews,
+					IncludeNotPlanned: opts.IncludeNotPlanned,
+					IssueAttribution:  opts.IssueAttribution,
+					Commits:           opts.Commits,
+				})
+				if j.err != nil {
+					cancel()
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		for _, client := range opts.Clients {
+			client.OnProgress = nil
+		}
+
+		// the first error that is not caused by cancelling the other jobs
+		for _, j := range jobs {
+			if j.err != nil && !errors.Is(j.err, context.Canceled) {
+				return fmt.Errorf("%s: %s: %w", j.client.Host, j.user, j.err)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// node ids are unique per host, results are merged in user order so an item credited to
+		// several users consistently goes to the last of them, issues go to the user with the
+		// strongest attribution first
+		for _, j := range jobs {
+			gh.MergeCredit(credit, j.credit)
+		}
+
+		return nil
+	}
+
+	var err error
+	if opts.Interactive() {
+		err = tui.RunWithSpinner(ctx, "Fetching issues from github", fetch)
+	} else {
+		err = fetch(ctx, func(string) {})
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err != nil {
+		return types.Credit{}, err
+	}
+
+	if opts.useState && !opts.IncludeExported {
+		unexported, skipped := opts.State.Unexported(credit)
+		if skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Skipping %d previously exported issues, use --include-exported to include them\n", skipped)
+		}
+		credit = unexported
+	}
+
+	credit.Fold(opts.Fold)
+
+	return credit, nil
+}
+
+// Interactive reports whether prompts and the TUI may be used, which requires stdin to be a
+// terminal and --no-tui to be unset
+func (opts *FetchOptions) Interactive() bool {
+	return !opts.NoTUI && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// progressStatus formats fetch progress for the spinner
+func progressStatus(progress gh.Progress) string {
+	status := progress.Message
+
+	if progress.Total > 0 {
+		status = fmt.Sprintf("%s (%d/%d)", status, progress.Fetched, progress.Total)
+	}
+
+	if resetAt, err := time.Parse(time.RFC3339, progress.RateLimit.ResetAt); err == nil {
+		status = fmt.Sprintf("%s · %d API points left, resets at %s", status, progress.RateLimit.Remaining, resetAt.Local().Format("15:04"))
+	}
+
+	return status
+}
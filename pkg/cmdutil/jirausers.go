@@ -0,0 +1,58 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/jira"
+	"github.com/spf13/cobra"
+)
+
+// JiraUserOptions holds the flags mapping GitHub logins onto Jira users for commands exporting to Jira
+type JiraUserOptions struct {
+	UserMap     string
+	LookupUsers bool
+}
+
+// AddJiraUserFlags registers the JiraUserOptions flags on cmd
+func AddJiraUserFlags(cmd *cobra.Command, opts *JiraUserOptions) {
+	cmd.Flags().StringVar(&opts.UserMap, "user-map", "", "Json file mapping GitHub logins to Jira users (default jira.usersFile from the config file)")
+	cmd.Flags().BoolVar(&opts.LookupUsers, "lookup-users", false, "Search Jira for GitHub logins without a mapped Jira user (default jira.lookupUsers from the config file)")
+}
+
+// MapJiraUsers sets the Jira user of every login in cfg.Users, from the mapping file and config or
+// by searching Jira when enabled. --user-map replaces jira.usersFile, the users defined in the
+// config file itself win over both. Logins without a Jira user are listed in a warning on stderr
+// and exported as is.
+func (opts *JiraUserOptions) MapJiraUsers(ctx context.Context, cfg *config.JiraConfig, logins []string) error {
+	if opts.UserMap != "" {
+		users, err := config.LoadUsers(opts.UserMap)
+		if err != nil {
+			return fmt.Errorf("invalid --user-map: %w", err)
+		}
+		cfg.ReplaceUsersFile(users)
+	}
+
+	var searcher jira.UserSearcher
+	if opts.LookupUsers || cfg.LookupUsers {
+		if cfg.URL == "" || cfg.Token == "" {
+			return fmt.Errorf("a Jira url and token are required to look up users, set JIRA_URL and JIRA_API_TOKEN or jira.url and jira.token in the config file")
+		}
+		searcher = jira.NewClient(cfg.URL, cfg.Email, cfg.Token, cfg.APIVersion)
+	}
+
+	mapped, unmapped, err := jira.MapUsers(ctx, logins, cfg.Users, searcher)
+	if err != nil {
+		return err
+	}
+	cfg.Users = mapped
+
+	if len(unmapped) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no Jira user for %d GitHub logins, they are exported as is: %s\n", len(unmapped), strings.Join(unmapped, ", "))
+	}
+
+	return nil
+}
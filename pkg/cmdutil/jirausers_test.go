@@ -0,0 +1,75 @@
+package cmdutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/config"
+)
+
+func TestMapJiraUsers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	configPath := write("config.json", `{"jira": {
+		"usersFile": "users.json",
+		"users": {"octocat": {"accountId": "inline-octocat"}}
+	}}`)
+	write("users.json", `{
+		"octocat": {"accountId": "file-octocat"},
+		"hubot": {"accountId": "file-hubot"},
+		"monalisa": {"accountId": "file-monalisa"}
+	}`)
+	userMap := write("user-map.json", `{
+		"octocat": {"accountId": "map-octocat"},
+		"hubot": {"accountId": "map-hubot"}
+	}`)
+
+	tests := []struct {
+		name    string
+		userMap string
+		want    map[string]string
+	}{
+		{
+			name: "config file",
+			want: map[string]string{"octocat": "inline-octocat", "hubot": "file-hubot", "monalisa": "file-monalisa"},
+		},
+		{
+			// --user-map replaces usersFile, the inline users still win
+			name:    "user map",
+			userMap: userMap,
+			want:    map[string]string{"octocat": "inline-octocat", "hubot": "map-hubot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			opts := &JiraUserOptions{UserMap: tt.userMap}
+			if err := opts.MapJiraUsers(context.Background(), &cfg.Jira, []string{"octocat", "hubot", "monalisa"}); err != nil {
+				t.Fatalf("MapJiraUsers() error = %v", err)
+			}
+
+			got := map[string]string{}
+			for login, user := range cfg.Jira.Users {
+				got[login] = user.AccountId
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("users = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package cmdutil
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// readUsersFile returns the users listed in path, one per line. Blank lines and lines starting
+// with # are ignored.
+func readUsersFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var users []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		users = append(users, strings.TrimPrefix(line, "@"))
+	}
+
+	return users, scanner.Err()
+}
+
+// uniqueUsers removes empty and repeated logins, ignoring case, keeping the first occurrence
+func uniqueUsers(users []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+
+	for _, user := range users {
+		user = strings.TrimSpace(user)
+		key := strings.ToLower(user)
+		if user == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, user)
+	}
+
+	return unique
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/gh"
+)
+
+// ErrNoToken is returned when no token source has a token for the host
+var ErrNoToken = errors.New("no github token found")
+
+// Token is a github token and the source it was found in
+type Token struct {
+	Value  string
+	Source string
+}
+
+// Options are the explicitly configured token sources
+type Options struct {
+	// Token is a token given on the command line, used for every host
+	Token string
+	// TokenFile is a file holding a bare token or hostname=token lines
+	TokenFile string
+}
+
+const (
+	// gitCredentialTimeout bounds how long a git credential helper may take
+	gitCredentialTimeout = 10 * time.Second
+	// ghAuthTokenTimeout bounds how long gh auth token may take to read the system keyring
+	ghAuthTokenTimeout = 10 * time.Second
+)
+
+// DefaultTokenFile returns the default token file location, $XDG_CONFIG_HOME/credit/token
+func DefaultTokenFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "credit", "token"), nil
+}
+
+// Resolve finds a token for hostname, trying in order the --token flag, the GH_TOKEN and
+// GITHUB_TOKEN environment variables (GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN for
+// enterprise hosts), the gh CLI hosts.yml or keyring, git credential fill and the token file
+func Resolve(ctx context.Context, hostname string, opts Options) (Token, error) {
+	if opts.Token != "" {
+		return Token{Value: opts.Token, Source: "--token flag"}, nil
+	}
+
+	envVars := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if gh.IsEnterprise(hostname) {
+		envVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+
+	for _, envVar := range envVars {
+		if token := os.Getenv(envVar); token != "" {
+			return Token{Value: token, Source: envVar}, nil
+		}
+	}
+
+	if path, token, err := ghCliToken(ctx, hostname); err != nil {
+		return Token{}, err
+	} else if token != "" {
+		return Token{Value: token, Source: path}, nil
+	}
+
+	if token := gitCredentialToken(ctx, hostname); token != "" {
+		return Token{Value: token, Source: "git credential fill"}, nil
+	}
+
+	if path, token, err := fileToken(hostname, opts.TokenFile); err != nil {
+		return Token{}, err
+	} else if token != "" {
+		return Token{Value: token, Source: path}, nil
+	}
+
+	return Token{}, fmt.Errorf("%w for %s", ErrNoToken, hostname)
+}
+
+// ghCliToken reads the oauth token for hostname from the gh CLI hosts.yml, falling back to
+// gh auth token for tokens gh keeps in the system keyring rather than in hosts.yml
+func ghCliToken(ctx context.Context, hostname string) (string, string, error) {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", "", nil
+			}
+			configHome = filepath.Join(home, ".config")
+		}
+		dir = filepath.Join(configHome, "gh")
+	}
+
+	path := filepath.Join(dir, "hosts.yml")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", "", err
+	}
+
+	if token := hostsYamlToken(data, hostname); token != "" {
+		return path, token, nil
+	}
+
+	return "gh auth token", ghAuthToken(ctx, hostname), nil
+}
+
+// ghAuthToken asks the gh CLI for the token of hostname, empty when gh is not installed or not
+// logged in to hostname
+func ghAuthToken(ctx context.Context, hostname string) string {
+	ctx, cancel := context.WithTimeout(ctx, ghAuthTokenTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", hostname).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// hostsYamlToken finds the oauth_token of hostname in a gh CLI hosts.yml, preferring the token
+// of the active user directly under the host over the per user tokens nested below it
+func hostsYamlToken(data []byte, hostname string) string {
+	var token string
+	tokenIndent := -1
+	inHost := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			inHost = strings.TrimSuffix(trimmed, ":") == hostname
+			continue
+		}
+
+		if !inHost {
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || key != "oauth_token" {
+			continue
+		}
+
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value != "" && (tokenIndent == -1 || indent < tokenIndent) {
+			token = value
+			tokenIndent = indent
+		}
+	}
+
+	return token
+}
+
+// gitCredentialToken asks the configured git credential helpers for the password of hostname
+func gitCredentialToken(ctx context.Context, hostname string) string {
+	ctx, cancel := context.WithTimeout(ctx, gitCredentialTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", hostname))
+	// never let git prompt for a username and password
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "password=") {
+			return strings.TrimPrefix(line, "password=")
+		}
+	}
+
+	return ""
+}
+
+// fileToken reads the token for hostname from the token file, either a bare token used for every
+// host or hostname=token lines
+func fileToken(hostname string, path string) (string, string, error) {
+	explicit := path != ""
+	if !explicit {
+		defaultPath, err := DefaultTokenFile()
+		if err != nil {
+			return "", "", nil
+		}
+		path = defaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	if !explicit && errors.Is(err, fs.ErrNotExist) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+
+	var bare string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		host, token, ok := strings.Cut(line, "=")
+		if !ok {
+			bare = line
+			continue
+		}
+
+		if strings.TrimSpace(host) == hostname {
+			return path, strings.TrimSpace(token), nil
+		}
+	}
+
+	return path, bare, nil
+}
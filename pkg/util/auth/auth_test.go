@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestHostsYamlToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		hostname string
+		want     string
+	}{
+		{
+			name: "single host",
+			data: `github.com:
+    user: octocat
+    oauth_token: gho_single
+    git_protocol: https
+`,
+			hostname: "github.com",
+			want:     "gho_single",
+		},
+		{
+			name: "quoted token",
+			data: `github.com:
+    oauth_token: "gho_quoted"
+`,
+			hostname: "github.com",
+			want:     "gho_quoted",
+		},
+		{
+			name: "active user over per user tokens",
+			data: `github.com:
+    users:
+        hubot:
+            oauth_token: gho_hubot
+        octocat:
+            oauth_token: gho_octocat
+    oauth_token: gho_active
+    user: octocat
+`,
+			hostname: "github.com",
+			want:     "gho_active",
+		},
+		{
+			name: "per user token without an active one",
+			data: `github.com:
+    users:
+        octocat:
+            oauth_token: gho_octocat
+    user: octocat
+`,
+			hostname: "github.com",
+			want:     "gho_octocat",
+		},
+		{
+			name: "enterprise host",
+			data: `github.com:
+    oauth_token: gho_public
+# comment
+ghe.example.com:
+    oauth_token: gho_enterprise
+`,
+			hostname: "ghe.example.com",
+			want:     "gho_enterprise",
+		},
+		{
+			name: "token stored in the keyring",
+			data: `github.com:
+    user: octocat
+    git_protocol: https
+`,
+			hostname: "github.com",
+		},
+		{
+			name: "unknown host",
+			data: `github.com:
+    oauth_token: gho_public
+`,
+			hostname: "ghe.example.com",
+		},
+		{
+			name:     "empty file",
+			hostname: "github.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostsYamlToken([]byte(tt.data), tt.hostname); got != tt.want {
+				t.Errorf("hostsYamlToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGhCliTokenKeyring(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gh CLI is a shell script")
+	}
+
+	// a gh CLI that only knows a token for github.com
+	bin := t.TempDir()
+	script := "#!/bin/sh\n[ \"$4\" = github.com ] && echo gho_keyring || exit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	config := t.TempDir()
+	t.Setenv("GH_CONFIG_DIR", config)
+	hosts := "github.com:\n    user: octocat\nghe.example.com:\n    oauth_token: gho_file\n"
+	if err := os.WriteFile(filepath.Join(config, "hosts.yml"), []byte(hosts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		hostname   string
+		wantSource string
+		wantToken  string
+	}{
+		{hostname: "github.com", wantSource: "gh auth token", wantToken: "gho_keyring"},
+		{hostname: "ghe.example.com", wantSource: filepath.Join(config, "hosts.yml"), wantToken: "gho_file"},
+		{hostname: "other.example.com", wantSource: "gh auth token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			source, token, err := ghCliToken(context.Background(), tt.hostname)
+			if err != nil {
+				t.Fatalf("ghCliToken() error = %v", err)
+			}
+			if token != tt.wantToken || (token != "" && source != tt.wantSource) {
+				t.Errorf("ghCliToken() = %q, %q, want %q, %q", source, token, tt.wantSource, tt.wantToken)
+			}
+		})
+	}
+}
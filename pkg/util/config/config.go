@@ -0,0 +1,333 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// Config is the credit configuration file
+type Config struct {
+	Jira JiraConfig `json:"jira"`
+}
+
+// JiraConfig controls how credit items are mapped onto Jira issues
+type JiraConfig struct {
+	// URL is the base url of the Jira site e.g. https://example.atlassian.net
+	URL string `json:"url"`
+	// Email is the account used to authenticate with an API token, leave empty to use
+	// Token as a personal access token
+	Email string `json:"email"`
+	// Token is the API token or personal access token used to authenticate
+	Token string `json:"token"`
+	// APIVersion is the Jira REST API version, 2 or 3
+	APIVersion int `json:"apiVersion"`
+	// Project is the Jira project key issues are imported into
+	Project string `json:"project"`
+	// IssueTypes maps a credit item type (pr, issue, review) to a Jira issue type
+	IssueTypes map[string]string `json:"issueTypes"`
+	// Columns is the ordered list of CSV columns to export
+	Columns []Column `json:"columns"`
+	// Users maps GitHub logins to Jira users, merged over the users of UsersFile
+	Users map[string]JiraUser `json:"users"`
+	// UsersFile is a json file mapping GitHub logins to Jira users like Users, relative paths are
+	// resolved from the config file
+	UsersFile string `json:"usersFile"`
+	// inlineUsers are the Users defined in the config file itself
+	inlineUsers map[string]JiraUser
+	// UserField is the Jira user field written to user columns: accountId (default), name, email
+	// or displayName
+	UserField string `json:"userField"`
+	// LookupUsers searches the Jira user search API for GitHub logins missing from Users
+	LookupUsers bool `json:"lookupUsers"`
+	// StoryPoints estimates merged PRs from their size for the size and points fields
+	StoryPoints StoryPoints `json:"storyPoints"`
+}
+
+// StoryPoints maps a PR metric onto size buckets e.g.
+// {"metric": "lines", "buckets": [{"size": "S", "max": 50, "points": 1}, {"size": "XL", "points": 8}]}
+type StoryPoints struct {
+	// Metric is the PR metric compared with the bucket thresholds, one of types.Metrics,
+	// lines when empty
+	Metric string `json:"metric"`
+	// Buckets are ordered by ascending Max, the last one may leave Max unset to catch all
+	// larger PRs
+	Buckets []SizeBucket `json:"buckets"`
+}
+
+// SizeBucket is a size PRs up to Max of the metric fall into
+type SizeBucket struct {
+	Size   string  `json:"size"`
+	Max    int     `json:"max,omitempty"`
+	Points float64 `json:"points"`
+}
+
+// Estimate returns the first bucket metrics fit into, ok is false when no bucket is configured
+// or the PR is larger than all of them
+func (s StoryPoints) Estimate(metrics types.PrMetrics) (bucket SizeBucket, ok bool) {
+	value := metrics.Value(s.Metric)
+
+	for _, bucket := range s.Buckets {
+		if bucket.Max == 0 || value <= bucket.Max {
+			return bucket, true
+		}
+	}
+
+	return SizeBucket{}, false
+}
+
+// validate returns an error for an unknown metric or buckets out of order
+func (s StoryPoints) validate() error {
+	if s.Metric != "" && !contains(types.Metrics, s.Metric) {
+		return fmt.Errorf("storyPoints metric must be one of %s", strings.Join(types.Metrics, ", "))
+	}
+
+	for i, bucket := range s.Buckets {
+		if bucket.Size == "" {
+			return fmt.Errorf("storyPoints bucket is missing a size")
+		}
+		if i == 0 {
+			continue
+		}
+		if previous := s.Buckets[i-1]; previous.Max == 0 || (bucket.Max != 0 && bucket.Max <= previous.Max) {
+			return fmt.Errorf("storyPoints buckets must be ordered by ascending max, with only the last one unbounded")
+		}
+	}
+
+	return nil
+}
+
+// JiraUser is the Jira user a GitHub login maps to
+type JiraUser struct {
+	// AccountId identifies the user on Jira Cloud
+	AccountId string `json:"accountId,omitempty"`
+	// Name is the username on Jira Server and Data Center
+	Name        string `json:"name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// UserFields lists the values accepted by JiraConfig.UserField
+var UserFields = []string{"accountId", "name", "email", "displayName"}
+
+// Field returns the named field of the user, see UserFields
+func (u JiraUser) Field(name string) string {
+	switch name {
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
+	case "displayName":
+		return u.DisplayName
+	default:
+		return u.AccountId
+	}
+}
+
+// User returns the configured user field of the Jira user login maps to, or login itself when it
+// is not mapped
+func (c JiraConfig) User(login string) string {
+	if user, ok := c.Users[login]; ok {
+		if value := user.Field(c.UserField); value != "" {
+			return value
+		}
+	}
+	return login
+}
+
+// ApplyEnv overrides the Jira site and credentials with the JIRA_URL, JIRA_EMAIL and
+// JIRA_API_TOKEN environment variables
+func (c *JiraConfig) ApplyEnv() {
+	if url, ok := os.LookupEnv("JIRA_URL"); ok {
+		c.URL = url
+	}
+	if email, ok := os.LookupEnv("JIRA_EMAIL"); ok {
+		c.Email = email
+	}
+	if token, ok := os.LookupEnv("JIRA_API_TOKEN"); ok {
+		c.Token = token
+	}
+}
+
+// Column maps a credit field or a constant value onto a Jira CSV column
+type Column struct {
+	// Name is the CSV header, usually the Jira field name e.g. Summary
+	Name string `json:"name"`
+	// Field is the credit field the value is taken from e.g. title
+	Field string `json:"field,omitempty"`
+	// Value is a constant used when Field is empty e.g. a sprint name
+	Value string `json:"value,omitempty"`
+	// JiraField is the Jira field id the column is mapped to in the importer configuration
+	// e.g. summary or customfield_10016
+	JiraField string `json:"jiraField,omitempty"`
+}
+
+// ColumnFields lists the credit fields accepted by Column.Field
+var ColumnFields = []string{"id", "kind", "type", "title", "description", "assignee", "reporter", "repo", "epic", "url", "labels", "created", "resolved",
+	"additions", "deletions", "files", "commits", "reviews", "comments", "size", "points"}
+
+// DefaultColumns is the column mapping used when the config file does not define one
+var DefaultColumns = []Column{
+	{Name: "Summary", Field: "title", JiraField: "summary"},
+	{Name: "Description", Field: "description", JiraField: "description"},
+	{Name: "Assignee", Field: "assignee", JiraField: "assignee"},
+	{Name: "Reporter", Field: "reporter", JiraField: "reporter"},
+	{Name: "Issue Type", Field: "type", JiraField: "issuetype"},
+	{Name: "Labels", Field: "labels", JiraField: "labels"},
+	{Name: "Components", Field: "repo", JiraField: "components"},
+	{Name: "Epic Link", Field: "epic"},
+	{Name: "Created", Field: "created", JiraField: "created"},
+	{Name: "Resolved", Field: "resolved", JiraField: "resolutiondate"},
+	// Story Points is a custom field, filled in when storyPoints buckets are configured
+	{Name: "Story Points", Field: "points"},
+}
+
+// DefaultIssueTypes is the issue type mapping used when the config file does not define one.
+// Reviews are exported as their own Review issue type, which has to exist in the Jira project.
+var DefaultIssueTypes = map[string]string{
+	"pr":         "Task",
+	"issue":      "Task",
+	"review":     "Review",
+	"commits":    "Task",
+	"repository": "Task",
+}
+
+// DefaultPath returns the default config file location, $XDG_CONFIG_HOME/credit/config.json
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "credit", "config.json"), nil
+}
+
+// Load reads the config file at path, or the default location if path is empty.
+// A missing default config file is not an error, the defaults are used instead.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	explicit := path != ""
+	if !explicit {
+		defaultPath, err := DefaultPath()
+		if err != nil {
+			return withDefaults(cfg), nil
+		}
+		path = defaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return withDefaults(cfg), nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	cfg.Jira.inlineUsers = cfg.Jira.Users
+
+	for _, column := range cfg.Jira.Columns {
+		if column.Name == "" {
+			return nil, fmt.Errorf("invalid config file %s: column is missing a name", path)
+		}
+		if column.Field != "" && !contains(ColumnFields, column.Field) {
+			return nil, fmt.Errorf("invalid config file %s: field %q of column %s must be one of %s", path, column.Field, column.Name, strings.Join(ColumnFields, ", "))
+		}
+	}
+
+	if cfg.Jira.UserField != "" && !contains(UserFields, cfg.Jira.UserField) {
+		return nil, fmt.Errorf("invalid config file %s: userField must be one of %s", path, strings.Join(UserFields, ", "))
+	}
+
+	if err := cfg.Jira.StoryPoints.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if cfg.Jira.UsersFile != "" {
+		usersPath := cfg.Jira.UsersFile
+		if !filepath.IsAbs(usersPath) {
+			usersPath = filepath.Join(filepath.Dir(path), usersPath)
+		}
+
+		users, err := LoadUsers(usersPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for login, user := range cfg.Jira.Users {
+			users[login] = user
+		}
+		cfg.Jira.Users = users
+	}
+
+	return withDefaults(cfg), nil
+}
+
+// ReplaceUsersFile replaces the users read from UsersFile with users, the Users defined in the
+// config file itself are still merged over them
+func (c *JiraConfig) ReplaceUsersFile(users map[string]JiraUser) {
+	for login, user := range c.inlineUsers {
+		users[login] = user
+	}
+	c.Users = users
+}
+
+// LoadUsers reads a json file mapping GitHub logins to Jira users e.g.
+// {"ffalor": {"accountId": "5b10ac8d82e05b22cc7d4ef5"}}
+func LoadUsers(path string) (map[string]JiraUser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]JiraUser)
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("could not parse users file %s: %w", path, err)
+	}
+
+	return users, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func withDefaults(cfg *Config) *Config {
+	if cfg.Jira.APIVersion == 0 {
+		cfg.Jira.APIVersion = 2
+	}
+
+	if len(cfg.Jira.Columns) == 0 {
+		cfg.Jira.Columns = DefaultColumns
+	}
+
+	if cfg.Jira.Users == nil {
+		cfg.Jira.Users = make(map[string]JiraUser)
+	}
+
+	if cfg.Jira.IssueTypes == nil {
+		cfg.Jira.IssueTypes = make(map[string]string)
+	}
+
+	for kind, issueType := range DefaultIssueTypes {
+		if _, ok := cfg.Jira.IssueTypes[kind]; !ok {
+			cfg.Jira.IssueTypes[kind] = issueType
+		}
+	}
+
+	return cfg
+}
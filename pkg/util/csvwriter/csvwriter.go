@@ -2,45 +2,145 @@ package csvwriter
 
 import (
 	"encoding/csv"
-	"fmt"
-	"os"
-	"strings"
+	"encoding/json"
+	"io"
 
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/record"
 	"github.com/ffalor/credit/pkg/util/types"
 )
 
-type Writer struct{}
+type Writer struct {
+	config config.JiraConfig
+}
 
-func NewWriter() *Writer {
-	return &Writer{}
+func NewWriter(cfg config.JiraConfig) *Writer {
+	return &Writer{
+		config: cfg,
+	}
 }
 
-func (w *Writer) Write(user string, allMergedPrs map[string]types.MergedPr, allIssues map[string]types.Issue) error {
-	file, err := os.Create("issues.csv")
-	defer file.Close()
+// Write exports all credit as csv into out using the configured column mapping
+func (w *Writer) Write(out io.Writer, user string, credit types.Credit) error {
+	records := record.Build(user, credit)
+	record.Estimate(records, w.config.StoryPoints)
 
+	rows, err := w.rows(records)
 	if err != nil {
 		return err
 	}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	return csv.NewWriter(out).WriteAll(rows)
+}
+
+// rows builds the csv header and one row per record.
+// A field with multiple values, such as labels, is spread over repeated columns as Jira expects.
+func (w *Writer) rows(records []record.Record) ([][]string, error) {
+	widths := make([]int, len(w.config.Columns))
+	values := make([][][]string, len(records))
+
+	for i, r := range records {
+		values[i] = make([][]string, len(w.config.Columns))
+
+		for j, column := range w.config.Columns {
+			value := []string{column.Value}
+
+			if column.Field != "" {
+				fieldValue, err := r.Field(column.Field, w.config.IssueTypes)
+				if err != nil {
+					return nil, err
+				}
+				value = fieldValue
 
-	writer.Write([]string{"title", "description", "assignee", "repo", "type"})
+				// Jira only accepts its own users, GitHub logins are replaced by their mapped user
+				if column.Field == "assignee" || column.Field == "reporter" {
+					for k, login := range value {
+						value[k] = w.config.User(login)
+					}
+				}
+			}
 
-	for _, pr := range allMergedPrs {
-		body := fmt.Sprintf("%s\nURL: %s", pr.Body, pr.Url)
-		writer.Write([]string{pr.Title, body, user, pr.RepoName, "pr"})
+			values[i][j] = value
+			if len(value) > widths[j] {
+				widths[j] = len(value)
+			}
+		}
+	}
+
+	var header []string
+	for j, column := range w.config.Columns {
+		if widths[j] == 0 {
+			widths[j] = 1
+		}
+		for k := 0; k < widths[j]; k++ {
+			header = append(header, column.Name)
+		}
 	}
 
-	for _, issue := range allIssues {
-		body := fmt.Sprintf("%s\nURL: %s", issue.Body, issue.Url)
-		if len(issue.Labels) > 0 {
-			body = fmt.Sprintf("%s\nLabels: %s", body, strings.Join(issue.Labels, ", "))
+	rows := [][]string{header}
+	for i := range records {
+		var row []string
+		for j := range w.config.Columns {
+			for k := 0; k < widths[j]; k++ {
+				var cell string
+				if k < len(values[i][j]) {
+					cell = values[i][j][k]
+				}
+				row = append(row, cell)
+			}
 		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// importerConfig is the saved configuration format of the Jira CSV importer
+type importerConfig struct {
+	Version        string                       `json:"config.version"`
+	ProjectFromCsv string                       `json:"config.project.from.csv"`
+	Encoding       string                       `json:"config.encoding"`
+	EmailSuffix    string                       `json:"config.email.suffix"`
+	FieldMappings  map[string]importerFieldMap  `json:"config.field.mappings"`
+	ValueMappings  map[string]map[string]string `json:"config.value.mappings"`
+	Delimiter      string                       `json:"config.delimiter"`
+	Project        importerProject              `json:"config.project"`
+	DateFormat     string                       `json:"config.date.format"`
+}
+
+type importerFieldMap struct {
+	JiraField string `json:"jira.field"`
+}
+
+type importerProject struct {
+	Key string `json:"project.key"`
+}
+
+// WriteImporterConfig writes a Jira importer configuration matching the column mapping into out,
+// columns without a JiraField are left for manual mapping in the import wizard
+func (w *Writer) WriteImporterConfig(out io.Writer) error {
+	fieldMappings := make(map[string]importerFieldMap)
+
+	for _, column := range w.config.Columns {
+		if column.JiraField == "" {
+			continue
+		}
+		fieldMappings[column.Name] = importerFieldMap{JiraField: column.JiraField}
+	}
 
-		writer.Write([]string{issue.Title, body, user, issue.RepoName, "issue"})
+	cfg := importerConfig{
+		Version:        "2.0",
+		ProjectFromCsv: "false",
+		Encoding:       "UTF-8",
+		EmailSuffix:    "@",
+		FieldMappings:  fieldMappings,
+		ValueMappings:  map[string]map[string]string{},
+		Delimiter:      ",",
+		Project:        importerProject{Key: w.config.Project},
+		DateFormat:     record.JiraDateFormat,
 	}
 
-	return nil
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cfg)
 }
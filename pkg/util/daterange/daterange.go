@@ -0,0 +1,143 @@
+package daterange
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateFormat is the format dates are accepted and searched in
+const DateFormat = "2006-01-02"
+
+// Names lists the named ranges accepted by Parse, besides Qn-YYYY, H1-YYYY, H2-YYYY and YYYY
+var Names = []string{"this-week", "last-week", "last-sprint", "this-month", "last-month", "this-quarter", "last-quarter", "this-year", "last-year"}
+
+var (
+	quarterPattern = regexp.MustCompile(`^[Qq]([1-4])-(\d{4})$`)
+	halfPattern    = regexp.MustCompile(`^[Hh]([12])-(\d{4})$`)
+	yearPattern    = regexp.MustCompile(`^(\d{4})$`)
+)
+
+// Range is an inclusive range of days, a zero To means the range is open ended
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Parse parses a date range given as a single start date (YYYY-MM-DD), a github style range
+// (YYYY-MM-DD..YYYY-MM-DD) or a named range such as last-quarter or H1-2025, relative to now
+func Parse(value string, now time.Time) (Range, error) {
+	value = strings.TrimSpace(value)
+	today := day(now)
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		var r Range
+		var err error
+
+		if from != "*" {
+			if r.From, err = time.Parse(DateFormat, from); err != nil {
+				return Range{}, fmt.Errorf("invalid start date %q, please use YYYY-MM-DD", from)
+			}
+		}
+		if to != "*" {
+			if r.To, err = time.Parse(DateFormat, to); err != nil {
+				return Range{}, fmt.Errorf("invalid end date %q, please use YYYY-MM-DD", to)
+			}
+		}
+		if !r.To.IsZero() && r.To.Before(r.From) {
+			return Range{}, fmt.Errorf("invalid range %q, the end date is before the start date", value)
+		}
+
+		return r, nil
+	}
+
+	if date, err := time.Parse(DateFormat, value); err == nil {
+		return Range{From: date}, nil
+	}
+
+	if match := quarterPattern.FindStringSubmatch(value); match != nil {
+		quarter, _ := strconv.Atoi(match[1])
+		year, _ := strconv.Atoi(match[2])
+		return months(year, time.Month(quarter*3-2), 3), nil
+	}
+
+	if match := halfPattern.FindStringSubmatch(value); match != nil {
+		half, _ := strconv.Atoi(match[1])
+		year, _ := strconv.Atoi(match[2])
+		return months(year, time.Month(half*6-5), 6), nil
+	}
+
+	if match := yearPattern.FindStringSubmatch(value); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		return months(year, time.January, 12), nil
+	}
+
+	// weeks start on monday
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	quarterStart := time.Month((int(today.Month())-1)/3*3 + 1)
+
+	switch strings.ToLower(value) {
+	case "this-week":
+		return Range{From: weekStart, To: today}, nil
+	case "last-week":
+		return Range{From: weekStart.AddDate(0, 0, -7), To: weekStart.AddDate(0, 0, -1)}, nil
+	case "last-sprint":
+		// the two full weeks before the current week
+		return Range{From: weekStart.AddDate(0, 0, -14), To: weekStart.AddDate(0, 0, -1)}, nil
+	case "this-month":
+		return Range{From: months(today.Year(), today.Month(), 1).From, To: today}, nil
+	case "last-month":
+		return months(today.Year(), today.Month()-1, 1), nil
+	case "this-quarter":
+		return Range{From: months(today.Year(), quarterStart, 3).From, To: today}, nil
+	case "last-quarter":
+		return months(today.Year(), quarterStart-3, 3), nil
+	case "this-year":
+		return Range{From: months(today.Year(), time.January, 12).From, To: today}, nil
+	case "last-year":
+		return months(today.Year()-1, time.January, 12), nil
+	}
+
+	return Range{}, fmt.Errorf("invalid date or range %q, please use YYYY-MM-DD, YYYY-MM-DD..YYYY-MM-DD, Qn-YYYY, Hn-YYYY, YYYY or one of: %s", value, strings.Join(Names, ", "))
+}
+
+// Qualifier returns the range in github search syntax, e.g. 2024-01-01..2024-03-31
+func (r Range) Qualifier() string {
+	switch {
+	case r.From.IsZero() && r.To.IsZero():
+		return "*"
+	case r.To.IsZero():
+		return ">=" + r.From.Format(DateFormat)
+	case r.From.IsZero():
+		return "<=" + r.To.Format(DateFormat)
+	}
+
+	return fmt.Sprintf("%s..%s", r.From.Format(DateFormat), r.To.Format(DateFormat))
+}
+
+// String returns the range as YYYY-MM-DD..YYYY-MM-DD, using * for an open end
+func (r Range) String() string {
+	from, to := "*", "*"
+	if !r.From.IsZero() {
+		from = r.From.Format(DateFormat)
+	}
+	if !r.To.IsZero() {
+		to = r.To.Format(DateFormat)
+	}
+
+	return fmt.Sprintf("%s..%s", from, to)
+}
+
+// months returns the range covering count months starting at month, month may be out of range
+// and is normalized e.g. month 0 is december of the previous year
+func months(year int, month time.Month, count int) Range {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return Range{From: from, To: from.AddDate(0, count, -1)}
+}
+
+// day truncates t to midnight UTC of the same calendar day
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
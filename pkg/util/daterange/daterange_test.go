@@ -0,0 +1,117 @@
+package daterange
+
+import (
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(DateFormat, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	// a wednesday
+	now := time.Date(2025, time.May, 14, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{value: "2024-01-01", wantFrom: "2024-01-01"},
+		{value: " 2024-01-01 ", wantFrom: "2024-01-01"},
+		{value: "2024-01-01..2024-03-31", wantFrom: "2024-01-01", wantTo: "2024-03-31"},
+		{value: "2024-01-01..*", wantFrom: "2024-01-01"},
+		{value: "*..2024-03-31", wantTo: "2024-03-31"},
+		{value: "2024-01-01..2024-01-01", wantFrom: "2024-01-01", wantTo: "2024-01-01"},
+		{value: "Q1-2025", wantFrom: "2025-01-01", wantTo: "2025-03-31"},
+		{value: "q4-2024", wantFrom: "2024-10-01", wantTo: "2024-12-31"},
+		{value: "H1-2025", wantFrom: "2025-01-01", wantTo: "2025-06-30"},
+		{value: "H2-2024", wantFrom: "2024-07-01", wantTo: "2024-12-31"},
+		{value: "2024", wantFrom: "2024-01-01", wantTo: "2024-12-31"},
+		{value: "this-week", wantFrom: "2025-05-12", wantTo: "2025-05-14"},
+		{value: "last-week", wantFrom: "2025-05-05", wantTo: "2025-05-11"},
+		{value: "last-sprint", wantFrom: "2025-04-28", wantTo: "2025-05-11"},
+		{value: "this-month", wantFrom: "2025-05-01", wantTo: "2025-05-14"},
+		{value: "last-month", wantFrom: "2025-04-01", wantTo: "2025-04-30"},
+		{value: "this-quarter", wantFrom: "2025-04-01", wantTo: "2025-05-14"},
+		{value: "last-quarter", wantFrom: "2025-01-01", wantTo: "2025-03-31"},
+		{value: "this-year", wantFrom: "2025-01-01", wantTo: "2025-05-14"},
+		{value: "last-year", wantFrom: "2024-01-01", wantTo: "2024-12-31"},
+		{value: "Last-Month", wantFrom: "2025-04-01", wantTo: "2025-04-30"},
+		{value: "2024-03-31..2024-01-01", wantErr: true},
+		{value: "2024-13-01", wantErr: true},
+		{value: "2024-01-01..tomorrow", wantErr: true},
+		{value: "Q5-2025", wantErr: true},
+		{value: "next-week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var want Range
+			if tt.wantFrom != "" {
+				want.From = date(tt.wantFrom)
+			}
+			if tt.wantTo != "" {
+				want.To = date(tt.wantTo)
+			}
+			if !got.From.Equal(want.From) || !got.To.Equal(want.To) {
+				t.Errorf("Parse() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseLastQuarterInJanuary(t *testing.T) {
+	got, err := Parse("last-quarter", time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if want := (Range{From: date("2024-10-01"), To: date("2024-12-31")}); got != want {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+}
+
+func TestQualifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		r     Range
+		want  string
+		wantS string
+	}{
+		{name: "unbounded", r: Range{}, want: "*", wantS: "*..*"},
+		{name: "closed", r: Range{From: date("2024-01-01"), To: date("2024-03-31")}, want: "2024-01-01..2024-03-31", wantS: "2024-01-01..2024-03-31"},
+		{name: "single day", r: Range{From: date("2024-01-01"), To: date("2024-01-01")}, want: "2024-01-01..2024-01-01", wantS: "2024-01-01..2024-01-01"},
+		// the range is inclusive, the first day has to be searched as well
+		{name: "open end", r: Range{From: date("2024-01-01")}, want: ">=2024-01-01", wantS: "2024-01-01..*"},
+		{name: "open start", r: Range{To: date("2024-03-31")}, want: "<=2024-03-31", wantS: "*..2024-03-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Qualifier(); got != tt.want {
+				t.Errorf("Qualifier() = %q, want %q", got, tt.want)
+			}
+			if got := tt.r.String(); got != tt.wantS {
+				t.Errorf("String() = %q, want %q", got, tt.wantS)
+			}
+		})
+	}
+}
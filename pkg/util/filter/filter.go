@@ -0,0 +1,135 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Visibility values
+const (
+	Public  = "public"
+	Private = "private"
+)
+
+// Filter limits credit to repositories by owner, name and visibility. The zero value matches
+// every repository.
+type Filter struct {
+	// Orgs are the owners to include
+	Orgs []string
+	// Repos are owner/name patterns to include, a pattern without an owner matches the name in
+	// any owner. Patterns may use path.Match globs e.g. ffalor/*.
+	Repos []string
+	// ExcludeRepos are patterns like Repos to leave out
+	ExcludeRepos []string
+	// Visibility is public or private, empty includes both
+	Visibility string
+}
+
+// Validate returns an error for a malformed repository pattern or unknown visibility
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Repos...), f.ExcludeRepos...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		}
+		if strings.Count(pattern, "/") > 1 {
+			return fmt.Errorf("invalid repository pattern %q, please use owner/name or name", pattern)
+		}
+	}
+
+	switch f.Visibility {
+	case "", Public, Private:
+		return nil
+	default:
+		return fmt.Errorf("invalid visibility %q, please use %s or %s", f.Visibility, Public, Private)
+	}
+}
+
+// Qualifiers returns the search qualifiers narrowing a search to the filter. Globs can not be
+// searched for, so results still have to be checked with Match.
+func (f Filter) Qualifiers() string {
+	var qualifiers []string
+
+	// several org, user and repo qualifiers are combined with OR, they can only be used when
+	// every included pattern can be expressed as one
+	var scopes []string
+	scoped := true
+	for _, org := range f.Orgs {
+		scopes = append(scopes, "org:"+org)
+	}
+	for _, pattern := range f.Repos {
+		owner, name, ok := strings.Cut(pattern, "/")
+		switch {
+		case !ok || IsGlob(owner):
+			scoped = false
+		case IsGlob(name):
+			scopes = append(scopes, "user:"+owner)
+		default:
+			scopes = append(scopes, "repo:"+pattern)
+		}
+	}
+	if scoped {
+		qualifiers = append(qualifiers, scopes...)
+	}
+
+	for _, pattern := range f.ExcludeRepos {
+		if strings.Contains(pattern, "/") && !IsGlob(pattern) {
+			qualifiers = append(qualifiers, "-repo:"+pattern)
+		}
+	}
+
+	if f.Visibility != "" {
+		qualifiers = append(qualifiers, "is:"+f.Visibility)
+	}
+
+	return strings.Join(qualifiers, " ")
+}
+
+// Match reports whether the repository nameWithOwner e.g. ffalor/credit passes the filter
+func (f Filter) Match(nameWithOwner string, private bool) bool {
+	switch {
+	case f.Visibility == Public && private, f.Visibility == Private && !private:
+		return false
+	}
+
+	for _, pattern := range f.ExcludeRepos {
+		if matchRepo(pattern, nameWithOwner) {
+			return false
+		}
+	}
+
+	if len(f.Orgs) == 0 && len(f.Repos) == 0 {
+		return true
+	}
+
+	owner, _, _ := strings.Cut(nameWithOwner, "/")
+	for _, org := range f.Orgs {
+		if strings.EqualFold(org, owner) {
+			return true
+		}
+	}
+
+	for _, pattern := range f.Repos {
+		if matchRepo(pattern, nameWithOwner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchRepo matches nameWithOwner against an owner/name or name pattern, ignoring case
+func matchRepo(pattern string, nameWithOwner string) bool {
+	pattern, nameWithOwner = strings.ToLower(pattern), strings.ToLower(nameWithOwner)
+	if !strings.Contains(pattern, "/") {
+		_, nameWithOwner, _ = strings.Cut(nameWithOwner, "/")
+	}
+
+	ok, _ := path.Match(pattern, nameWithOwner)
+	return ok
+}
+
+// IsGlob reports whether pattern uses path.Match wildcards
+func IsGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[\\")
+}
@@ -0,0 +1,104 @@
+package filter
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        Filter
+		nameWithOwner string
+		private       bool
+		want          bool
+	}{
+		{name: "zero value matches everything", nameWithOwner: "ffalor/credit", want: true},
+		{name: "zero value matches private", nameWithOwner: "ffalor/credit", private: true, want: true},
+		{name: "org", filter: Filter{Orgs: []string{"ffalor"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "org ignores case", filter: Filter{Orgs: []string{"FFalor"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "other org", filter: Filter{Orgs: []string{"acme"}}, nameWithOwner: "ffalor/credit"},
+		{name: "repo", filter: Filter{Repos: []string{"ffalor/credit"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "repo ignores case", filter: Filter{Repos: []string{"FFalor/Credit"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "other repo", filter: Filter{Repos: []string{"ffalor/credit"}}, nameWithOwner: "ffalor/other"},
+		{name: "owner glob", filter: Filter{Repos: []string{"ffalor/*"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "owner glob other owner", filter: Filter{Repos: []string{"ffalor/*"}}, nameWithOwner: "acme/credit"},
+		{name: "name glob", filter: Filter{Repos: []string{"ffalor/cred*"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "name in any owner", filter: Filter{Repos: []string{"credit"}}, nameWithOwner: "acme/credit", want: true},
+		{name: "name glob in any owner", filter: Filter{Repos: []string{"*-api"}}, nameWithOwner: "acme/billing-api", want: true},
+		{name: "name does not match the owner", filter: Filter{Repos: []string{"ffalor"}}, nameWithOwner: "ffalor/credit"},
+		{name: "org or repo", filter: Filter{Orgs: []string{"acme"}, Repos: []string{"ffalor/credit"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "excluded repo", filter: Filter{ExcludeRepos: []string{"ffalor/credit"}}, nameWithOwner: "ffalor/credit"},
+		{name: "excluded glob", filter: Filter{ExcludeRepos: []string{"ffalor/*"}}, nameWithOwner: "ffalor/credit"},
+		{name: "excluded name", filter: Filter{ExcludeRepos: []string{"credit"}}, nameWithOwner: "acme/credit"},
+		{name: "not excluded", filter: Filter{ExcludeRepos: []string{"ffalor/other"}}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "exclusion wins over org", filter: Filter{Orgs: []string{"ffalor"}, ExcludeRepos: []string{"ffalor/credit"}}, nameWithOwner: "ffalor/credit"},
+		{name: "exclusion wins over repo", filter: Filter{Repos: []string{"ffalor/*"}, ExcludeRepos: []string{"*/credit"}}, nameWithOwner: "ffalor/credit"},
+		{name: "public", filter: Filter{Visibility: Public}, nameWithOwner: "ffalor/credit", want: true},
+		{name: "public leaves out private", filter: Filter{Visibility: Public}, nameWithOwner: "ffalor/credit", private: true},
+		{name: "private", filter: Filter{Visibility: Private}, nameWithOwner: "ffalor/credit", private: true, want: true},
+		{name: "private leaves out public", filter: Filter{Visibility: Private}, nameWithOwner: "ffalor/credit"},
+		{name: "visibility and org", filter: Filter{Orgs: []string{"ffalor"}, Visibility: Private}, nameWithOwner: "ffalor/credit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.nameWithOwner, tt.private); got != tt.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tt.nameWithOwner, tt.private, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualifiers(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{name: "zero value", want: ""},
+		{name: "orgs", filter: Filter{Orgs: []string{"ffalor", "acme"}}, want: "org:ffalor org:acme"},
+		{name: "repo", filter: Filter{Repos: []string{"ffalor/credit"}}, want: "repo:ffalor/credit"},
+		{name: "owner glob searches the owner", filter: Filter{Repos: []string{"ffalor/*"}}, want: "user:ffalor"},
+		{name: "orgs and repos", filter: Filter{Orgs: []string{"acme"}, Repos: []string{"ffalor/credit"}}, want: "org:acme repo:ffalor/credit"},
+		// a name in any owner can not be searched, so no scope can be searched at all
+		{name: "name without owner", filter: Filter{Orgs: []string{"acme"}, Repos: []string{"credit"}}, want: ""},
+		{name: "glob owner", filter: Filter{Repos: []string{"*/credit"}}, want: ""},
+		{name: "excluded repo", filter: Filter{ExcludeRepos: []string{"ffalor/credit"}}, want: "-repo:ffalor/credit"},
+		{name: "excluded globs are only matched", filter: Filter{ExcludeRepos: []string{"ffalor/*", "credit"}}, want: ""},
+		{name: "visibility", filter: Filter{Visibility: Private}, want: "is:private"},
+		{
+			name:   "everything",
+			filter: Filter{Orgs: []string{"acme"}, Repos: []string{"ffalor/*"}, ExcludeRepos: []string{"acme/legacy"}, Visibility: Public},
+			want:   "org:acme user:ffalor -repo:acme/legacy is:public",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Qualifiers(); got != tt.want {
+				t.Errorf("Qualifiers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantErr bool
+	}{
+		{name: "zero value"},
+		{name: "patterns", filter: Filter{Repos: []string{"ffalor/*", "credit"}, ExcludeRepos: []string{"*-api"}, Visibility: Public}},
+		{name: "malformed glob", filter: Filter{Repos: []string{"ffalor/[credit"}}, wantErr: true},
+		{name: "malformed exclusion", filter: Filter{ExcludeRepos: []string{"[credit"}}, wantErr: true},
+		{name: "too many slashes", filter: Filter{Repos: []string{"ffalor/credit/main"}}, wantErr: true},
+		{name: "unknown visibility", filter: Filter{Visibility: "internal"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package gh
+
+import (
+	"strings"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// Strategies crediting a closed issue to a user
+const (
+	// AttributionAuthor credits issues the user opened
+	AttributionAuthor = "author"
+	// AttributionAssignee credits issues assigned to the user
+	AttributionAssignee = "assignee"
+	// AttributionCloser credits issues the user closed. Search can not look for the closer, so
+	// candidates are the issues the user is involved in, checked against the closed event.
+	AttributionCloser = "closer"
+	// AttributionPrAuthor credits issues closed by a merged PR the user authored
+	AttributionPrAuthor = "pr-author"
+)
+
+// Attributions lists the valid values of Options.IssueAttribution
+var Attributions = []string{AttributionAuthor, AttributionAssignee, AttributionCloser, AttributionPrAuthor}
+
+// DefaultAttribution credits the issues the user opened or closed with a PR
+var DefaultAttribution = []string{AttributionAuthor, AttributionPrAuthor}
+
+// attributionPrecedence orders the strategies by how closely they tie an issue to the work of a
+// user, an issue credited to several users goes to the one with the strongest attribution
+var attributionPrecedence = []string{AttributionAssignee, AttributionCloser, AttributionPrAuthor, AttributionAuthor}
+
+// issueQualifiers are the search qualifiers finding candidate issues for each strategy,
+// issues credited for a PR are found through the PR instead
+var issueQualifiers = map[string]string{
+	AttributionAuthor:   "author",
+	AttributionAssignee: "assignee",
+	AttributionCloser:   "involves",
+}
+
+// attributions returns the strategies of opts in order of preference
+func (opts Options) attributions() []string {
+	if len(opts.IssueAttribution) == 0 {
+		return DefaultAttribution
+	}
+	return opts.IssueAttribution
+}
+
+// attribute returns the first strategy crediting the issue to the user, or an empty string when
+// none does. viaPr is true when the issue was closed by a merged PR of the user.
+func attribute(node types.IssueNode, opts Options, viaPr bool) string {
+	for _, strategy := range opts.attributions() {
+		switch strategy {
+		case AttributionAuthor:
+			if strings.EqualFold(node.Author.Login, opts.User) {
+				return strategy
+			}
+		case AttributionAssignee:
+			for _, assignee := range node.Assignees.Nodes {
+				if strings.EqualFold(assignee.Login, opts.User) {
+					return strategy
+				}
+			}
+		case AttributionCloser:
+			for _, event := range node.TimelineItems.Nodes {
+				if strings.EqualFold(event.ClosedEvent.Actor.Login, opts.User) {
+					return strategy
+				}
+			}
+		case AttributionPrAuthor:
+			if viaPr {
+				return strategy
+			}
+		}
+	}
+
+	return ""
+}
+
+// MergeCredit adds all items of other to credit. An issue credited to a user in both keeps the
+// user with the stronger attribution, see attributionPrecedence, and goes to the user of other
+// when they are equally strong.
+func MergeCredit(credit types.Credit, other types.Credit) {
+	issues := make(map[string]types.Issue, len(other.Issues))
+	for id, issue := range other.Issues {
+		if existing, ok := credit.Issues[id]; ok && precedence(existing.Attribution) < precedence(issue.Attribution) {
+			continue
+		}
+		issues[id] = issue
+	}
+
+	other.Issues = issues
+	credit.Merge(other)
+}
+
+// precedence returns the rank of strategy in attributionPrecedence, lower is stronger
+func precedence(strategy string) int {
+	for i, s := range attributionPrecedence {
+		if s == strategy {
+			return i
+		}
+	}
+	return len(attributionPrecedence)
+}
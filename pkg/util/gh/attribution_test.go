@@ -0,0 +1,115 @@
+package gh
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+func TestMergeCredit(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		other    string
+		wantUser string
+	}{
+		{name: "assignee over author", existing: AttributionAssignee, other: AttributionAuthor, wantUser: "octocat"},
+		{name: "closer over author", existing: AttributionAuthor, other: AttributionCloser, wantUser: "hubot"},
+		{name: "assignee over closer", existing: AttributionCloser, other: AttributionAssignee, wantUser: "hubot"},
+		{name: "pr author over author", existing: AttributionPrAuthor, other: AttributionAuthor, wantUser: "octocat"},
+		{name: "closer over pr author", existing: AttributionPrAuthor, other: AttributionCloser, wantUser: "hubot"},
+		{name: "tie goes to the later user", existing: AttributionAuthor, other: AttributionAuthor, wantUser: "hubot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credit := types.NewCredit()
+			credit.Issues["I_1"] = types.Issue{Id: "I_1", User: "octocat", Attribution: tt.existing}
+
+			other := types.NewCredit()
+			other.Issues["I_1"] = types.Issue{Id: "I_1", User: "hubot", Attribution: tt.other}
+			other.Issues["I_2"] = types.Issue{Id: "I_2", User: "hubot", Attribution: AttributionAuthor}
+			other.MergedPrs["PR_1"] = types.MergedPr{Id: "PR_1", User: "hubot"}
+
+			MergeCredit(credit, other)
+
+			if got := credit.Issues["I_1"].User; got != tt.wantUser {
+				t.Errorf("issue credited to %s, want %s", got, tt.wantUser)
+			}
+			if _, ok := credit.Issues["I_2"]; !ok {
+				t.Error("issue only credited to the other user is missing")
+			}
+			if _, ok := credit.MergedPrs["PR_1"]; !ok {
+				t.Error("merged PR of the other user is missing")
+			}
+			// other is left as is
+			if got := other.Issues["I_1"].User; got != "hubot" {
+				t.Errorf("other issue credited to %s, want hubot", got)
+			}
+		})
+	}
+}
+
+// issueNode decodes an issue from json, with inline fragments nested under their field name
+func issueNode(t *testing.T, data string) types.IssueNode {
+	t.Helper()
+
+	var node types.IssueNode
+	if err := json.Unmarshal([]byte(data), &node); err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestAttribute(t *testing.T) {
+	// opened by octocat, assigned to hubot and mona and closed by hubot
+	shared := `{
+		"author": {"login": "octocat"},
+		"assignees": {"nodes": [{"login": "mona"}, {"login": "Hubot"}]},
+		"timelineItems": {"nodes": [{"closedEvent": {"actor": {"login": "hubot"}}}]}
+	}`
+	// opened and closed by octocat
+	own := `{
+		"author": {"login": "octocat"},
+		"timelineItems": {"nodes": [{"closedEvent": {"actor": {"login": "octocat"}}}]}
+	}`
+
+	tests := []struct {
+		name        string
+		issue       string
+		user        string
+		attribution []string
+		viaPr       bool
+		want        string
+	}{
+		{name: "author", issue: shared, user: "octocat", attribution: []string{AttributionAuthor}, want: AttributionAuthor},
+		{name: "author ignores case", issue: shared, user: "OctoCat", attribution: []string{AttributionAuthor}, want: AttributionAuthor},
+		{name: "not the author", issue: shared, user: "hubot", attribution: []string{AttributionAuthor}},
+		{name: "assignee", issue: shared, user: "hubot", attribution: []string{AttributionAssignee}, want: AttributionAssignee},
+		{name: "any assignee", issue: shared, user: "mona", attribution: []string{AttributionAssignee}, want: AttributionAssignee},
+		{name: "not assigned", issue: own, user: "hubot", attribution: []string{AttributionAssignee}},
+		{name: "closer", issue: shared, user: "hubot", attribution: []string{AttributionCloser}, want: AttributionCloser},
+		{name: "not the closer", issue: shared, user: "mona", attribution: []string{AttributionCloser}},
+		{name: "pr author", issue: shared, user: "mona", attribution: []string{AttributionPrAuthor}, viaPr: true, want: AttributionPrAuthor},
+		{name: "pr author needs a pr", issue: shared, user: "mona", attribution: []string{AttributionPrAuthor}},
+		{name: "default is author", issue: own, user: "octocat", want: AttributionAuthor},
+		{name: "default falls back to pr author", issue: shared, user: "mona", viaPr: true, want: AttributionPrAuthor},
+		{name: "default leaves out assignees", issue: shared, user: "mona"},
+		// several strategies match, the first one given wins
+		{name: "assignee before closer", issue: shared, user: "hubot", attribution: []string{AttributionAssignee, AttributionCloser}, want: AttributionAssignee},
+		{name: "closer before assignee", issue: shared, user: "hubot", attribution: []string{AttributionCloser, AttributionAssignee}, want: AttributionCloser},
+		{name: "closer before author", issue: own, user: "octocat", attribution: []string{AttributionCloser, AttributionAuthor}, want: AttributionCloser},
+		{name: "author before pr author", issue: own, user: "octocat", attribution: []string{AttributionAuthor, AttributionPrAuthor}, viaPr: true, want: AttributionAuthor},
+		{name: "falls back to a later strategy", issue: shared, user: "mona", attribution: []string{AttributionAuthor, AttributionCloser, AttributionAssignee}, want: AttributionAssignee},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{User: tt.user, IssueAttribution: tt.attribution}
+			if got := attribute(issueNode(t, tt.issue), opts, tt.viaPr); got != tt.want {
+				t.Errorf("attribute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
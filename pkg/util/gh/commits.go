@@ -0,0 +1,253 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/filter"
+	"github.com/ffalor/credit/pkg/util/text"
+	"github.com/ffalor/credit/pkg/util/types"
+	"github.com/shurcooL/githubv4"
+)
+
+// Groupings of commits into credit items
+const (
+	// CommitsByDay credits the commits to a repository on one day as one item
+	CommitsByDay = "day"
+	// CommitsByRepo credits all commits to a repository as one item
+	CommitsByRepo = "repo"
+)
+
+// CommitGroupings lists the valid values of Options.Commits
+var CommitGroupings = []string{CommitsByDay, CommitsByRepo}
+
+// commit is a commit on a default branch that is not part of a merged PR
+type commit struct {
+	oid           string
+	headline      string
+	committedDate string
+}
+
+// getCommits adds the commits the user pushed within the range to the default branch of the
+// repositories matching the filter, leaving out commits that belong to a merged PR. The commits
+// are grouped into contributions as set by opts.Commits.
+func (g *Gh) getCommits(ctx context.Context, credit types.Credit, opts Options) error {
+	var userQuery types.UserIdQuery
+	if err := g.Client.Query(ctx, &userQuery, map[string]interface{}{"user": githubv4.String(opts.User)}); err != nil {
+		return err
+	}
+	g.rateLimit = userQuery.RateLimit
+
+	if userQuery.User.Id == "" {
+		return fmt.Errorf("user %s not found", opts.User)
+	}
+
+	repos, err := g.commitRepositories(ctx, opts.Filter)
+	if err != nil {
+		return err
+	}
+
+	for i, nameWithOwner := range repos {
+		g.report(Progress{
+			Message: fmt.Sprintf("Fetching commits to %s", nameWithOwner),
+			Fetched: i,
+			Total:   len(repos),
+		})
+
+		if err := g.repositoryCommits(ctx, credit, opts, userQuery.User.Id, nameWithOwner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// repositoryCommits adds the commits of the user with node id author to the default branch of
+// nameWithOwner to credit
+func (g *Gh) repositoryCommits(ctx context.Context, credit types.Credit, opts Options, author string, nameWithOwner string) error {
+	owner, name, _ := strings.Cut(nameWithOwner, "/")
+	authorId := githubv4.ID(author)
+
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(name),
+		"author": githubv4.CommitAuthor{ID: &authorId},
+		"since":  (*githubv4.GitTimestamp)(nil),
+		"until":  (*githubv4.GitTimestamp)(nil),
+		"cursor": (*githubv4.String)(nil),
+	}
+	if !opts.Range.From.IsZero() {
+		variables["since"] = &githubv4.GitTimestamp{Time: opts.Range.From}
+	}
+	if !opts.Range.To.IsZero() {
+		// the range is inclusive of its last day
+		variables["until"] = &githubv4.GitTimestamp{Time: opts.Range.To.AddDate(0, 0, 1).Add(-time.Second)}
+	}
+
+	var commits []commit
+	var query types.CommitHistoryQuery
+
+	for {
+		query = types.CommitHistoryQuery{}
+		if err := g.Client.Query(ctx, &query, variables); err != nil {
+			return err
+		}
+		g.rateLimit = query.RateLimit
+
+		repo := query.Repository
+		if !opts.Filter.Match(repo.NameWithOwner, repo.IsPrivate) {
+			return nil
+		}
+
+		history := repo.DefaultBranchRef.Target.Commit.History
+		for _, node := range history.Nodes {
+			if mergedPr(node.AssociatedPullRequests.Nodes) {
+				continue
+			}
+			commits = append(commits, commit{oid: node.Oid, headline: node.MessageHeadline, committedDate: node.CommittedDate})
+		}
+
+		if err := g.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		if !history.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = githubv4.NewString(githubv4.String(history.PageInfo.EndCursor))
+	}
+
+	repo := query.Repository
+	branchUrl := fmt.Sprintf("%s/commits/%s", repo.Url, repo.DefaultBranchRef.Name)
+
+	if opts.Commits == CommitsByRepo {
+		if len(commits) == 0 {
+			return nil
+		}
+
+		// the newest commit is part of the id, so commits pushed after an export are offered again
+		id := fmt.Sprintf("commits:%s:%s:%s", opts.User, repo.NameWithOwner, commits[0].oid)
+		credit.Contributions[id] = types.Contribution{
+			Id:         id,
+			Kind:       types.ContributionCommits,
+			RepoName:   repo.Name,
+			Title:      fmt.Sprintf("%s to %s", text.Plural(len(commits), "commit", "commits"), repo.NameWithOwner),
+			Body:       commitList(commits),
+			Url:        fmt.Sprintf("%s?author=%s", branchUrl, opts.User),
+			OccurredAt: commits[0].committedDate,
+		}
+		return nil
+	}
+
+	days := make(map[string][]commit)
+	for _, c := range commits {
+		day := c.committedDate
+		if t, err := time.Parse(time.RFC3339, c.committedDate); err == nil {
+			day = t.UTC().Format(daterange.DateFormat)
+		}
+		days[day] = append(days[day], c)
+	}
+
+	for day, dayCommits := range days {
+		id := fmt.Sprintf("commits:%s:%s:%s", opts.User, repo.NameWithOwner, day)
+		credit.Contributions[id] = types.Contribution{
+			Id:         id,
+			Kind:       types.ContributionCommits,
+			RepoName:   repo.Name,
+			Title:      fmt.Sprintf("%s to %s on %s", text.Plural(len(dayCommits), "commit", "commits"), repo.NameWithOwner, day),
+			Body:       commitList(dayCommits),
+			Url:        fmt.Sprintf("%s?author=%s&since=%s&until=%s", branchUrl, opts.User, day, day),
+			OccurredAt: dayCommits[0].committedDate,
+		}
+	}
+
+	return nil
+}
+
+// commitRepositories returns the sorted owner/name of the repositories matching f. Repositories
+// of the orgs and of owners with a name glob are listed, a pattern without an owner can not be
+// listed and is an error.
+func (g *Gh) commitRepositories(ctx context.Context, f filter.Filter) ([]string, error) {
+	if len(f.Orgs) == 0 && len(f.Repos) == 0 {
+		return nil, fmt.Errorf("commits can only be fetched for repositories selected with --org or --repo")
+	}
+
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(nameWithOwner string) {
+		if key := strings.ToLower(nameWithOwner); !seen[key] {
+			seen[key] = true
+			repos = append(repos, nameWithOwner)
+		}
+	}
+
+	owners := append([]string{}, f.Orgs...)
+	for _, pattern := range f.Repos {
+		owner, name, ok := strings.Cut(pattern, "/")
+		switch {
+		case !ok || filter.IsGlob(owner):
+			return nil, fmt.Errorf("can not list the repositories matching %q for commits, please include the owner", pattern)
+		case filter.IsGlob(name):
+			owners = append(owners, owner)
+		default:
+			add(pattern)
+		}
+	}
+
+	for _, owner := range owners {
+		variables := map[string]interface{}{
+			"owner":  githubv4.String(owner),
+			"cursor": (*githubv4.String)(nil),
+		}
+
+		for {
+			var query types.OwnerRepositoriesQuery
+			if err := g.Client.Query(ctx, &query, variables); err != nil {
+				return nil, err
+			}
+			g.rateLimit = query.RateLimit
+
+			repositories := query.RepositoryOwner.Repositories
+			for _, repo := range repositories.Nodes {
+				if f.Match(repo.NameWithOwner, repo.IsPrivate) {
+					add(repo.NameWithOwner)
+				}
+			}
+
+			if !repositories.PageInfo.HasNextPage {
+				break
+			}
+			variables["cursor"] = githubv4.NewString(githubv4.String(repositories.PageInfo.EndCursor))
+		}
+	}
+
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// mergedPr reports whether any of the PRs a commit belongs to was merged
+func mergedPr(prs []struct{ Merged bool }) bool {
+	for _, pr := range prs {
+		if pr.Merged {
+			return true
+		}
+	}
+	return false
+}
+
+// commitList describes commits as a markdown list of short hashes and headlines
+func commitList(commits []commit) string {
+	lines := make([]string, len(commits))
+	for i, c := range commits {
+		oid := c.oid
+		if len(oid) > 7 {
+			oid = oid[:7]
+		}
+		lines[i] = fmt.Sprintf("- %s %s", oid, c.headline)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,129 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/filter"
+	"github.com/shurcooL/githubv4"
+)
+
+// commitsServer is a stand-in for the graphql api answering the contributionsCollection and
+// commit history queries of a user with commits to o/r
+func commitsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	empty := func(connection string) string {
+		return `{"user":{"contributionsCollection":{"` + connection + `":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}`
+	}
+	responses := []struct {
+		match string
+		data  string
+	}{
+		{match: "pullRequestContributions", data: empty("pullRequestContributions")},
+		{match: "issueContributions", data: empty("issueContributions")},
+		{match: "repositoryContributions", data: empty("repositoryContributions")},
+		// the contributionsCollection counts commits of merged PRs as well
+		{match: "commitContributionsByRepository", data: `{"user":{"contributionsCollection":{"commitContributionsByRepository":[
+			{"repository":{"name":"r","nameWithOwner":"o/r","url":"https://github.com/o/r"},"contributions":{"nodes":[
+				{"occurredAt":"2024-01-02T08:00:00Z","commitCount":2},
+				{"occurredAt":"2024-01-03T08:00:00Z","commitCount":1}
+			]}}
+		]}}}`},
+		{match: "history(", data: `{"repository":{"name":"r","nameWithOwner":"o/r","url":"https://github.com/o/r","defaultBranchRef":{"name":"main","target":{"history":{
+			"pageInfo":{"hasNextPage":false},
+			"nodes":[
+				{"oid":"c3c3c3c3c3","messageHeadline":"merged","committedDate":"2024-01-03T08:00:00Z","associatedPullRequests":{"nodes":[{"merged":true}]}},
+				{"oid":"c2c2c2c2c2","messageHeadline":"second","committedDate":"2024-01-02T12:00:00Z","associatedPullRequests":{"nodes":[{"merged":false}]}},
+				{"oid":"c1c1c1c1c1","messageHeadline":"first","committedDate":"2024-01-02T08:00:00Z","associatedPullRequests":{"nodes":[]}}
+			]
+		}}}}}`},
+		{match: "user(", data: `{"user":{"id":"U_1"}}`},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		for _, response := range responses {
+			if strings.Contains(body.Query, response.match) {
+				var data map[string]interface{}
+				if err := json.Unmarshal([]byte(response.data), &data); err != nil {
+					t.Errorf("invalid stub response for %s: %v", response.match, err)
+				}
+				data["rateLimit"] = map[string]interface{}{"remaining": 5000}
+
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+				return
+			}
+		}
+		t.Errorf("unexpected query %s", body.Query)
+		http.Error(w, "unexpected query", http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCommitsWithContributionsSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits string
+		wantIds []string
+	}{
+		{
+			name: "contributions only",
+			// every day with commits, including the one with only merged PR commits
+			wantIds: []string{"commits:octocat:o/r:2024-01-02", "commits:octocat:o/r:2024-01-03"},
+		},
+		{
+			name:    "by day",
+			commits: CommitsByDay,
+			wantIds: []string{"commits:octocat:o/r:2024-01-02"},
+		},
+		{
+			name:    "by repo",
+			commits: CommitsByRepo,
+			// named after the newest commit that is not part of a merged PR
+			wantIds: []string{"commits:octocat:o/r:c2c2c2c2c2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := commitsServer(t)
+			g := &Gh{Client: githubv4.NewEnterpriseClient(srv.URL, http.DefaultClient)}
+
+			credit, err := g.GetIssues(context.Background(), Options{
+				User:    "octocat",
+				Range:   daterange.Range{From: day("2024-01-01"), To: day("2024-01-31")},
+				Source:  SourceContributions,
+				Filter:  filter.Filter{Repos: []string{"o/r"}},
+				Commits: tt.commits,
+			})
+			if err != nil {
+				t.Fatalf("GetIssues() error = %v", err)
+			}
+
+			var ids []string
+			for id := range credit.Contributions {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			if !reflect.DeepEqual(ids, tt.wantIds) {
+				t.Errorf("contributions = %v, want %v", ids, tt.wantIds)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/text"
+	"github.com/ffalor/credit/pkg/util/types"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	// contributionsWindow is the longest period a single contributionsCollection may span
+	contributionsWindow = 365 * 24 * time.Hour
+	// commitWindow keeps the days with commits per repository within a single page of 100
+	commitWindow = 100 * 24 * time.Hour
+	// openedLookback is how long before the range PRs and issues may have been opened and still be
+	// merged or closed within it
+	openedLookback = 365 * 24 * time.Hour
+)
+
+// getContributions returns the merged PRs, closed issues, commits and created repositories of
+// the user within the range in matching repositories from their contributionsCollection. PRs and
+// issues are contributed when opened, so the ones opened up to openedLookback before the range are
+// read as well and count when merged or closed within it. Issues the user did not open are
+// searched for when an attribution strategy other than author asks for them. Commits are left to
+// getCommits when opts.Commits is set, the contributionsCollection can not tell commits of merged
+// PRs apart.
+func (g *Gh) getContributions(ctx context.Context, opts Options) (types.Credit, error) {
+	credit := types.NewCredit()
+	user, dateRange, f := opts.User, opts.Range, opts.Filter
+
+	openedRange := dateRange
+	if !openedRange.From.IsZero() {
+		openedRange.From = openedRange.From.Add(-openedLookback)
+	}
+
+	err := g.contributions(ctx, "PR contributions", user, openedRange, func() types.ContributionsQuery { return &types.PrContributionsQuery{} }, func(page types.ContributionsQuery) {
+		query := page.(*types.PrContributionsQuery)
+
+		for _, node := range query.User.ContributionsCollection.PullRequestContributions.Nodes {
+			if node.PullRequest.Merged && inRange(node.PullRequest.MergedAt, dateRange) {
+				addMergedPr(credit, node.PullRequest, opts)
+			}
+		}
+	})
+	if err != nil {
+		return credit, err
+	}
+
+	err = g.contributions(ctx, "issue contributions", user, openedRange, func() types.ContributionsQuery { return &types.IssueContributionsQuery{} }, func(page types.ContributionsQuery) {
+		query := page.(*types.IssueContributionsQuery)
+
+		for _, node := range query.User.ContributionsCollection.IssueContributions.Nodes {
+			if node.Issue.Closed && inRange(node.Issue.ClosedAt, dateRange) {
+				addIssue(credit, node.Issue, opts, false)
+			}
+		}
+	})
+	if err != nil {
+		return credit, err
+	}
+
+	err = g.contributions(ctx, "repository contributions", user, dateRange, func() types.ContributionsQuery { return &types.RepositoryContributionsQuery{} }, func(page types.ContributionsQuery) {
+		query := page.(*types.RepositoryContributionsQuery)
+
+		for _, node := range query.User.ContributionsCollection.RepositoryContributions.Nodes {
+			repo := node.Repository
+			if !f.Match(repo.NameWithOwner, repo.IsPrivate) {
+				continue
+			}
+
+			credit.Contributions[repo.Id] = types.Contribution{
+				Id:         repo.Id,
+				Kind:       types.ContributionRepository,
+				RepoName:   repo.Name,
+				Title:      fmt.Sprintf("Created repository %s", repo.NameWithOwner),
+				Body:       repo.Description,
+				Url:        repo.Url,
+				OccurredAt: node.OccurredAt,
+			}
+		}
+	})
+	if err != nil {
+		return credit, err
+	}
+
+	var commitWindows []daterange.Range
+	if opts.Commits == "" {
+		commitWindows = contributionWindows(dateRange, commitWindow)
+	}
+
+	for _, window := range commitWindows {
+		variables := map[string]interface{}{
+			"user": githubv4.String(user),
+			"from": githubv4.DateTime{Time: window.From},
+			"to":   githubv4.DateTime{Time: window.To},
+		}
+
+		var query types.CommitContributionsQuery
+		if err := g.Client.Query(ctx, &query, variables); err != nil {
+			return credit, err
+		}
+		g.rateLimit = query.RateLimit
+
+		for _, repoContributions := range query.User.ContributionsCollection.CommitContributionsByRepository {
+			repo := repoContributions.Repository
+			if !f.Match(repo.NameWithOwner, repo.IsPrivate) {
+				continue
+			}
+
+			for _, node := range repoContributions.Contributions.Nodes {
+				day := node.OccurredAt
+				if t, err := time.Parse(time.RFC3339, node.OccurredAt); err == nil {
+					day = t.Format(daterange.DateFormat)
+				}
+
+				id := fmt.Sprintf("commits:%s:%s:%s", user, repo.NameWithOwner, day)
+				credit.Contributions[id] = types.Contribution{
+					Id:         id,
+					Kind:       types.ContributionCommits,
+					RepoName:   repo.Name,
+					Title:      fmt.Sprintf("%s to %s on %s", text.Plural(node.CommitCount, "commit", "commits"), repo.NameWithOwner, day),
+					Url:        fmt.Sprintf("%s/commits?author=%s&since=%s&until=%s", repo.Url, user, day, day),
+					OccurredAt: node.OccurredAt,
+				}
+			}
+		}
+
+		g.report(Progress{Message: fmt.Sprintf("Fetching commit contributions %s..%s", window.From.Format(daterange.DateFormat), window.To.Format(daterange.DateFormat))})
+
+		if err := g.waitForRateLimit(ctx); err != nil {
+			return credit, err
+		}
+	}
+
+	var strategies []string
+	for _, strategy := range opts.attributions() {
+		if strategy != AttributionAuthor {
+			strategies = append(strategies, strategy)
+		}
+	}
+
+	return credit, g.searchIssues(ctx, credit, opts, strategies)
+}
+
+// contributions calls handle with every page of the contributionsCollection connection queried by
+// newQuery, one contributions window after another. name describes the results in progress reports.
+func (g *Gh) contributions(ctx context.Context, name string, user string, dateRange daterange.Range, newQuery func() types.ContributionsQuery, handle func(types.ContributionsQuery)) error {
+	for _, window := range contributionWindows(dateRange, contributionsWindow) {
+		variables := map[string]interface{}{
+			"user":   githubv4.String(user),
+			"from":   githubv4.DateTime{Time: window.From},
+			"to":     githubv4.DateTime{Time: window.To},
+			"cursor": (*githubv4.String)(nil),
+		}
+
+		for {
+			query := newQuery()
+			if err := g.Client.Query(ctx, query, variables); err != nil {
+				return err
+			}
+			g.rateLimit = query.Rate()
+
+			handle(query)
+
+			g.report(Progress{Message: fmt.Sprintf("Fetching %s %s..%s", name, window.From.Format(daterange.DateFormat), window.To.Format(daterange.DateFormat))})
+
+			if err := g.waitForRateLimit(ctx); err != nil {
+				return err
+			}
+
+			pageInfo := query.Page()
+			if !pageInfo.HasNextPage {
+				break
+			}
+			variables["cursor"] = githubv4.NewString(githubv4.String(pageInfo.EndCursor))
+		}
+	}
+
+	return nil
+}
+
+// contributionWindows splits dateRange into windows no longer than size, a contributionsCollection
+// may span at most a year. An open ended range ends now.
+func contributionWindows(dateRange daterange.Range, size time.Duration) []daterange.Range {
+	from, to := dateRange.From, time.Now().UTC()
+	if from.IsZero() {
+		from = searchEpoch
+	}
+	if !dateRange.To.IsZero() {
+		// the range is inclusive of its last day
+		to = dateRange.To.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	var windows []daterange.Range
+	for start := from; start.Before(to); start = start.Add(size) {
+		end := start.Add(size - time.Second)
+		if end.After(to) {
+			end = to
+		}
+		windows = append(windows, daterange.Range{From: start, To: end})
+	}
+
+	return windows
+}
+
+// inRange reports whether the github timestamp falls within the days of dateRange
+func inRange(timestamp string, dateRange daterange.Range) bool {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false
+	}
+
+	if !dateRange.From.IsZero() && t.Before(dateRange.From) {
+		return false
+	}
+
+	return dateRange.To.IsZero() || t.Before(dateRange.To.AddDate(0, 0, 1))
+}
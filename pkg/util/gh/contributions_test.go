@@ -0,0 +1,173 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/shurcooL/githubv4"
+)
+
+func TestContributionWindows(t *testing.T) {
+	at := func(value string) time.Time {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name      string
+		dateRange daterange.Range
+		size      time.Duration
+		want      []daterange.Range
+	}{
+		{
+			name:      "single window includes the last day",
+			dateRange: daterange.Range{From: day("2024-01-01"), To: day("2024-03-31")},
+			size:      contributionsWindow,
+			want: []daterange.Range{
+				{From: at("2024-01-01T00:00:00Z"), To: at("2024-03-31T23:59:59Z")},
+			},
+		},
+		{
+			name:      "single day",
+			dateRange: daterange.Range{From: day("2024-01-01"), To: day("2024-01-01")},
+			size:      contributionsWindow,
+			want: []daterange.Range{
+				{From: at("2024-01-01T00:00:00Z"), To: at("2024-01-01T23:59:59Z")},
+			},
+		},
+		{
+			name:      "windows do not overlap",
+			dateRange: daterange.Range{From: day("2024-01-01"), To: day("2024-01-05")},
+			size:      2 * 24 * time.Hour,
+			want: []daterange.Range{
+				{From: at("2024-01-01T00:00:00Z"), To: at("2024-01-02T23:59:59Z")},
+				{From: at("2024-01-03T00:00:00Z"), To: at("2024-01-04T23:59:59Z")},
+				{From: at("2024-01-05T00:00:00Z"), To: at("2024-01-05T23:59:59Z")},
+			},
+		},
+		{
+			name:      "window size matches the range",
+			dateRange: daterange.Range{From: day("2024-01-01"), To: day("2024-01-02")},
+			size:      2 * 24 * time.Hour,
+			want: []daterange.Range{
+				{From: at("2024-01-01T00:00:00Z"), To: at("2024-01-02T23:59:59Z")},
+			},
+		},
+		{
+			name:      "open start begins at the search epoch",
+			dateRange: daterange.Range{To: day("2008-01-02")},
+			size:      contributionsWindow,
+			want: []daterange.Range{
+				{From: at("2008-01-01T00:00:00Z"), To: at("2008-01-02T23:59:59Z")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contributionWindows(tt.dateRange, tt.size)
+			if len(got) != len(tt.want) {
+				t.Fatalf("contributionWindows() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].From.Equal(tt.want[i].From) || !got[i].To.Equal(tt.want[i].To) {
+					t.Errorf("window %d = %v - %v, want %v - %v", i, got[i].From, got[i].To, tt.want[i].From, tt.want[i].To)
+				}
+			}
+		})
+	}
+}
+
+func TestContributionWindowsOpenEnd(t *testing.T) {
+	before := time.Now().UTC()
+	got := contributionWindows(daterange.Range{From: before.AddDate(0, 0, -3)}, contributionsWindow)
+
+	if len(got) != 1 {
+		t.Fatalf("contributionWindows() = %v, want a single window", got)
+	}
+	if got[0].To.Before(before) || got[0].To.After(time.Now().UTC()) {
+		t.Errorf("window ends %v, want now", got[0].To)
+	}
+}
+
+func TestContributionsMergedPrsOpenedBeforeRange(t *testing.T) {
+	// PR_1 was opened before the range and merged within it, PR_2 was merged before the range and
+	// PR_3 was opened more than openedLookback before the range
+	prs := []struct{ id, createdAt, mergedAt string }{
+		{id: "PR_1", createdAt: "2023-12-20T08:00:00Z", mergedAt: "2024-01-05T08:00:00Z"},
+		{id: "PR_2", createdAt: "2023-12-20T08:00:00Z", mergedAt: "2023-12-28T08:00:00Z"},
+		{id: "PR_3", createdAt: "2022-06-01T08:00:00Z", mergedAt: "2024-01-10T08:00:00Z"},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string `json:"query"`
+			Variables struct {
+				From time.Time `json:"from"`
+				To   time.Time `json:"to"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		var data string
+		switch {
+		case strings.Contains(body.Query, "pullRequestContributions"):
+			// the contributionsCollection lists PRs in the window they were opened in
+			var nodes []string
+			for _, pr := range prs {
+				createdAt, _ := time.Parse(time.RFC3339, pr.createdAt)
+				if createdAt.Before(body.Variables.From) || createdAt.After(body.Variables.To) {
+					continue
+				}
+				nodes = append(nodes, fmt.Sprintf(`{"pullRequest":{"id":%q,"merged":true,"createdAt":%q,"mergedAt":%q,"baseRepository":{"name":"r","nameWithOwner":"o/r"}}}`, pr.id, pr.createdAt, pr.mergedAt))
+			}
+			data = `{"user":{"contributionsCollection":{"pullRequestContributions":{"pageInfo":{"hasNextPage":false},"nodes":[` + strings.Join(nodes, ",") + `]}}}}`
+		case strings.Contains(body.Query, "issueContributions"):
+			data = `{"user":{"contributionsCollection":{"issueContributions":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}`
+		case strings.Contains(body.Query, "repositoryContributions"):
+			data = `{"user":{"contributionsCollection":{"repositoryContributions":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}`
+		case strings.Contains(body.Query, "commitContributionsByRepository"):
+			data = `{"user":{"contributionsCollection":{"commitContributionsByRepository":[]}}}`
+		default:
+			t.Errorf("unexpected query %s", body.Query)
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data":%s}`, strings.Replace(data, "{", `{"rateLimit":{"remaining":5000},`, 1))
+	}))
+	defer srv.Close()
+
+	g := &Gh{Client: githubv4.NewEnterpriseClient(srv.URL, http.DefaultClient)}
+	credit, err := g.GetIssues(context.Background(), Options{
+		User:   "octocat",
+		Range:  daterange.Range{From: day("2024-01-01"), To: day("2024-01-31")},
+		Source: SourceContributions,
+	})
+	if err != nil {
+		t.Fatalf("GetIssues() error = %v", err)
+	}
+
+	var ids []string
+	for id := range credit.MergedPrs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if want := []string{"PR_1"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("merged PRs = %v, want %v", ids, want)
+	}
+}
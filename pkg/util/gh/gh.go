@@ -2,121 +2,396 @@ package gh
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/filter"
 	"github.com/ffalor/credit/pkg/util/types"
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
 )
 
+// DefaultHost is the hostname of github.com
+const DefaultHost = "github.com"
+
 type Gh struct {
 	Token  string
+	Host   string
 	Client *githubv4.Client
+	// httpClient is the authenticated client used for REST requests
+	httpClient *http.Client
+	// OnProgress is called as results are fetched, requests are retried or the rate limit changes
+	OnProgress func(Progress)
+	rateLimit  types.RateLimit
+}
+
+// Progress reports the state of a running fetch
+type Progress struct {
+	// Message describes what is currently being fetched or waited on
+	Message string
+	// Fetched is the number of results of the current search fetched so far
+	Fetched int
+	// Total is the number of results of the current search
+	Total int
+	// RateLimit is the graphql rate limit budget reported by the last query
+	RateLimit types.RateLimit
+	// Warning is set instead of Message when results were left out of the fetch
+	Warning string
 }
 
-func NewGh(token string) *Gh {
+// NewGh returns a client for the github instance at hostname, an empty hostname or github.com
+// targets api.github.com and anything else a GitHub Enterprise Server
+func NewGh(token string, hostname string) *Gh {
+	if hostname == "" {
+		hostname = DefaultHost
+	}
+
+	g := &Gh{
+		Token: token,
+		Host:  hostname,
+	}
+
+	retryClient := &http.Client{
+		Transport: &retryTransport{
+			base: http.DefaultTransport,
+			onRetry: func(wait time.Duration, reason string) {
+				g.report(Progress{Message: fmt.Sprintf("Retrying in %s after %s", wait.Round(time.Second), reason)})
+			},
+		},
+	}
+
 	src := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	httpClient := oauth2.NewClient(context.Background(), src)
-	client := githubv4.NewClient(httpClient)
-
-	return &Gh{
-		Token:  token,
-		Client: client,
+	httpClient := oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, retryClient), src)
+	if IsEnterprise(hostname) {
+		g.Client = githubv4.NewEnterpriseClient(fmt.Sprintf("https://%s/api/graphql", hostname), httpClient)
+	} else {
+		g.Client = githubv4.NewClient(httpClient)
 	}
+	g.httpClient = httpClient
+
+	return g
 }
 
-// GetIssues returns all merged PRs and closed issues for a given user
-func (g *Gh) GetIssues(user string, fromDate string) (map[string]types.MergedPr, map[string]types.Issue, error) {
-	allMergedPrs := make(map[string]types.MergedPr)
-	allIssues := make(map[string]types.Issue)
+// IsEnterprise reports whether hostname is a GitHub Enterprise Server rather than github.com
+func IsEnterprise(hostname string) bool {
+	return hostname != DefaultHost && hostname != "api."+DefaultHost
+}
+
+// TokenInfo describes the user and oauth scopes of a token
+type TokenInfo struct {
+	Login string
+	// Scopes is empty for fine-grained tokens, which do not report scopes
+	Scopes []string
+}
+
+// TokenInfo returns the login and scopes of the client's token
+func (g *Gh) TokenInfo(ctx context.Context) (TokenInfo, error) {
+	url := "https://api.github.com/user"
+	if IsEnterprise(g.Host) {
+		url = fmt.Sprintf("https://%s/api/v3/user", g.Host)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return TokenInfo{}, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return TokenInfo{}, err
+	}
 
-	for {
-		variables := map[string]interface{}{
-			"query":        githubv4.String(fmt.Sprintf("is:pr is:merged author:%s merged:>%s", user, fromDate)),
-			"searchCursor": (*githubv4.String)(nil),
+	info := TokenInfo{Login: user.Login}
+	for _, scope := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			info.Scopes = append(info.Scopes, scope)
 		}
+	}
+
+	return info, nil
+}
+
+// Copy returns a client sharing g's connection with its own progress reporting and rate limit
+// tracking, so several fetches can run at once
+func (g *Gh) Copy() *Gh {
+	c := *g
+	c.OnProgress = nil
+	return &c
+}
+
+// report sends progress to OnProgress, filling in the last known rate limit
+func (g *Gh) report(progress Progress) {
+	if g.OnProgress == nil {
+		return
+	}
 
-		var query types.MergedPrQuery
+	if progress.RateLimit == (types.RateLimit{}) {
+		progress.RateLimit = g.rateLimit
+	}
+
+	g.OnProgress(progress)
+}
 
-		err := g.Client.Query(context.Background(), &query, variables)
+// Sources GetIssues can fetch credit from
+const (
+	// SourceSearch finds merged PRs and closed issues with the search api
+	SourceSearch = "search"
+	// SourceContributions reads the user's contributionsCollection, which also covers commits and
+	// created repositories and is not affected by search indexing
+	SourceContributions = "contributions"
+)
+
+// Sources lists the valid values of Options.Source
+var Sources = []string{SourceSearch, SourceContributions}
+
+// Options selects the credit fetched by GetIssues
+type Options struct {
+	User  string
+	Range daterange.Range
+	// Source is one of Sources, search when empty
+	Source string
+	// Filter limits credit to matching repositories
+	Filter filter.Filter
+	// Reviews includes reviews given on other people's PRs
+	Reviews bool
+	// IncludeNotPlanned includes issues closed as not planned
+	IncludeNotPlanned bool
+	// IssueAttribution are the Attributions crediting closed issues in order of preference,
+	// DefaultAttribution when empty
+	IssueAttribution []string
+	// Commits is one of CommitGroupings to include commits pushed to the default branch of the
+	// filtered repositories, empty to leave them out
+	Commits string
+}
+
+// GetIssues returns all merged PRs and closed issues for a given user within the date range,
+// and the reviews they gave if requested
+func (g *Gh) GetIssues(ctx context.Context, opts Options) (types.Credit, error) {
+	var credit types.Credit
+	var err error
+
+	switch opts.Source {
+	case SourceContributions:
+		credit, err = g.getContributions(ctx, opts)
+	case SourceSearch, "":
+		credit, err = g.searchCredit(ctx, opts)
+	default:
+		return types.Credit{}, fmt.Errorf("unknown source %q", opts.Source)
+	}
+	if err != nil {
+		return credit, err
+	}
+
+	if opts.Reviews {
+		reviews, err := g.getReviews(ctx, opts.User, opts.Range, opts.Filter)
 		if err != nil {
-			return allMergedPrs, allIssues, err
+			return credit, err
+		}
+		credit.Reviews = reviews
+	}
+
+	if opts.Commits != "" {
+		if err := g.getCommits(ctx, credit, opts); err != nil {
+			return credit, err
 		}
+	}
+
+	credit.Assign(opts.User)
+
+	return credit, nil
+}
+
+// searchCredit returns the merged PRs authored by the user and the issues credited to them that
+// were closed within the range in matching repositories using the search api
+func (g *Gh) searchCredit(ctx context.Context, opts Options) (types.Credit, error) {
+	credit := types.NewCredit()
+	user, dateRange, f := opts.User, opts.Range, opts.Filter
+
+	mergedPrSearch := func(r daterange.Range) string {
+		return scoped(fmt.Sprintf("is:pr is:merged author:%s merged:%s", user, r.Qualifier()), f)
+	}
+
+	err := g.search(ctx, "merged PRs", dateRange, mergedPrSearch, func() types.SearchQuery { return &types.MergedPrQuery{} }, func(page types.SearchQuery) {
+		query := page.(*types.MergedPrQuery)
 
 		for _, edge := range query.Search.Edges {
-			node := edge.Node.PullRequest
-			for _, issue := range node.ClosingIssuesReferences.Nodes {
-
-				var labels []string
-
-				for _, label := range issue.Labels.Nodes {
-					labels = append(labels, label.Name)
-				}
-
-				allIssues[issue.Id] = types.Issue{
-					Id:       issue.Id,
-					RepoName: node.BaseRepository.Name,
-					Body:     issue.Body,
-					Url:      issue.Url,
-					Title:    issue.Title,
-					Labels:   labels,
-				}
-			}
+			addMergedPr(credit, edge.Node.PullRequest, opts)
+		}
+	})
+	if err != nil {
+		return credit, err
+	}
 
-			allMergedPrs[node.Id] = types.MergedPr{
-				Id:        node.Id,
-				RepoName:  node.BaseRepository.Name,
-				Title:     node.Title,
-				Body:      node.Body,
-				Url:       node.Url,
-				CreatedAt: node.CreatedAt,
-				MergedAt:  node.MergedAt,
+	return credit, g.searchIssues(ctx, credit, opts, opts.attributions())
+}
+
+// searchIssues adds the issues closed within the range that strategies credit to the user,
+// running a search for each strategy that has a search qualifier
+func (g *Gh) searchIssues(ctx context.Context, credit types.Credit, opts Options, strategies []string) error {
+	for _, strategy := range strategies {
+		qualifier, ok := issueQualifiers[strategy]
+		if !ok {
+			continue
+		}
+
+		issueSearch := func(r daterange.Range) string {
+			query := fmt.Sprintf("is:issue is:closed %s:%s closed:%s", qualifier, opts.User, r.Qualifier())
+			if !opts.IncludeNotPlanned {
+				query += ` -reason:"not planned"`
 			}
+			return scoped(query, opts.Filter)
 		}
 
-		if !query.Search.PageInfo.HasNextPage {
-			break
+		name := fmt.Sprintf("closed issues (%s)", strategy)
+		err := g.search(ctx, name, opts.Range, issueSearch, func() types.SearchQuery { return &types.IssueQuery{} }, func(page types.SearchQuery) {
+			query := page.(*types.IssueQuery)
+
+			for _, node := range query.Search.Nodes {
+				addIssue(credit, node.Issue, opts, false)
+			}
+		})
+		if err != nil {
+			return err
 		}
+	}
 
-		variables["searchCursor"] = query.Search.PageInfo.EndCursor
+	return nil
+}
+
+// addMergedPr adds a merged PR and the issues it closed that are credited to the user to credit,
+// leaving out the PR and closed issues in repositories that do not match the filter
+func addMergedPr(credit types.Credit, node types.PullRequestNode, opts Options) {
+	if !opts.Filter.Match(node.BaseRepository.NameWithOwner, node.BaseRepository.IsPrivate) {
+		return
 	}
 
-	for {
-		variables := map[string]interface{}{
-			"query":        githubv4.String(fmt.Sprintf("is:issue is:closed author:%s closed:>%s", user, fromDate)),
-			"searchCursor": (*githubv4.String)(nil),
+	var closingIssueIds []string
+
+	for _, issueNode := range node.ClosingIssuesReferences.Nodes {
+		if !opts.Filter.Match(issueNode.Repository.NameWithOwner, issueNode.Repository.IsPrivate) {
+			continue
 		}
-		var query types.IssueQuery
+		closingIssueIds = append(closingIssueIds, issueNode.Id)
 
-		err := g.Client.Query(context.Background(), &query, variables)
-		if err != nil {
-			return allMergedPrs, allIssues, err
+		issue, ok := addIssue(credit, issueNode, opts, true)
+		if !ok {
+			continue
 		}
 
-		for _, node := range query.Search.Nodes {
-			issue := node.Issue
-			var labels []string
+		issue.ClosedBy = appendPullRequestRef(issue.ClosedBy, types.PullRequestRef{Id: node.Id, Title: node.Title, Url: node.Url})
+		credit.Issues[issue.Id] = issue
+	}
 
-			for _, label := range issue.Labels.Nodes {
-				labels = append(labels, label.Name)
-			}
+	credit.MergedPrs[node.Id] = types.MergedPr{
+		Id:              node.Id,
+		RepoName:        node.BaseRepository.Name,
+		Title:           node.Title,
+		Body:            node.Body,
+		Url:             node.Url,
+		CreatedAt:       node.CreatedAt,
+		MergedAt:        node.MergedAt,
+		ClosingIssueIds: closingIssueIds,
+		Metrics: types.PrMetrics{
+			Additions:    node.Additions,
+			Deletions:    node.Deletions,
+			ChangedFiles: node.ChangedFiles,
+			Commits:      node.Commits.TotalCount,
+			Reviews:      node.Reviews.TotalCount,
+			Comments:     node.Comments.TotalCount,
+			Fetched:      true,
+		},
+	}
+}
 
-			allIssues[issue.Id] = types.Issue{
-				Id:     issue.Id,
-				Body:   issue.Body,
-				Title:  issue.Title,
-				Labels: labels,
-			}
+// addIssue adds a closed issue to credit unless its repository does not match the filter, it
+// was closed as not planned or no attribution strategy credits it to the user, keeping the PRs
+// that closed it when it was added before. viaPr is true when the issue was found through a
+// merged PR of the user. ok is false when the issue was left out.
+func addIssue(credit types.Credit, node types.IssueNode, opts Options, viaPr bool) (issue types.Issue, ok bool) {
+	if !opts.Filter.Match(node.Repository.NameWithOwner, node.Repository.IsPrivate) {
+		return types.Issue{}, false
+	}
+	if node.StateReason == "NOT_PLANNED" && !opts.IncludeNotPlanned {
+		return types.Issue{}, false
+	}
+
+	issue = newIssue(node)
+	if existing, ok := credit.Issues[issue.Id]; ok {
+		viaPr = viaPr || existing.Attribution == AttributionPrAuthor
+		for _, pr := range existing.ClosedBy {
+			issue.ClosedBy = appendPullRequestRef(issue.ClosedBy, pr)
 		}
+	}
+
+	issue.Attribution = attribute(node, opts, viaPr)
+	if issue.Attribution == "" {
+		return types.Issue{}, false
+	}
 
-		if !query.Search.PageInfo.HasNextPage {
-			break
+	credit.Issues[issue.Id] = issue
+	return issue, true
+}
+
+// newIssue converts an issue node
+func newIssue(node types.IssueNode) types.Issue {
+	var labels []string
+	var assignees []string
+	var closedBy []types.PullRequestRef
+
+	for _, label := range node.Labels.Nodes {
+		labels = append(labels, label.Name)
+	}
+
+	for _, assignee := range node.Assignees.Nodes {
+		assignees = append(assignees, assignee.Login)
+	}
+
+	for _, event := range node.TimelineItems.Nodes {
+		if pr := event.ClosedEvent.Closer.PullRequest; pr.Id != "" {
+			closedBy = append(closedBy, types.PullRequestRef{Id: pr.Id, Title: pr.Title, Url: pr.Url})
 		}
+	}
 
-		variables["searchCursor"] = query.Search.PageInfo.EndCursor
+	return types.Issue{
+		Id:          node.Id,
+		RepoName:    node.Repository.Name,
+		Body:        node.Body,
+		Url:         node.Url,
+		Title:       node.Title,
+		ClosedAt:    node.ClosedAt,
+		Labels:      labels,
+		StateReason: node.StateReason,
+		Milestone:   node.Milestone.Title,
+		Assignees:   assignees,
+		ClosedBy:    closedBy,
 	}
+}
 
-	return allMergedPrs, allIssues, nil
+// appendPullRequestRef adds pr to refs unless it is already there
+func appendPullRequestRef(refs []types.PullRequestRef, pr types.PullRequestRef) []types.PullRequestRef {
+	for _, ref := range refs {
+		if ref.Id == pr.Id {
+			return refs
+		}
+	}
+	return append(refs, pr)
 }
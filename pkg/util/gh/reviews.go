@@ -0,0 +1,92 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/filter"
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// getReviews returns the reviews user gave on other people's PRs within dateRange in repositories
+// matching f, one per PR keeping the latest review. Reviews come from the user's review contributions, and from a
+// reviewed-by search to include PRs that are not counted as contributions.
+func (g *Gh) getReviews(ctx context.Context, user string, dateRange daterange.Range, f filter.Filter) (map[string]types.Review, error) {
+	// latest review per PR id
+	byPr := make(map[string]types.Review)
+	add := func(review types.Review) {
+		if !inRange(review.SubmittedAt, dateRange) {
+			return
+		}
+		if existing, ok := byPr[review.PrId]; ok && existing.SubmittedAt >= review.SubmittedAt {
+			return
+		}
+		byPr[review.PrId] = review
+	}
+
+	err := g.contributions(ctx, "review contributions", user, dateRange, func() types.ContributionsQuery { return &types.ReviewContributionsQuery{} }, func(page types.ContributionsQuery) {
+		query := page.(*types.ReviewContributionsQuery)
+
+		for _, node := range query.User.ContributionsCollection.PullRequestReviewContributions.Nodes {
+			pr := node.PullRequest
+			if !f.Match(pr.BaseRepository.NameWithOwner, pr.BaseRepository.IsPrivate) {
+				continue
+			}
+			add(newReview(node.PullRequestReview, pr.Id, pr.Title, pr.Url, pr.BaseRepository.Name))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a PR is updated whenever it is reviewed, so PRs reviewed within the range were updated since its start
+	reviewedSearch := func(r daterange.Range) string {
+		return scoped(fmt.Sprintf("is:pr reviewed-by:%s -author:%s updated:%s", user, user, r.Qualifier()), f)
+	}
+
+	searchRange := daterange.Range{From: dateRange.From}
+	err = g.search(ctx, "reviewed PRs", searchRange, reviewedSearch, func() types.SearchQuery { return &types.ReviewedPrQuery{} }, func(page types.SearchQuery) {
+		query := page.(*types.ReviewedPrQuery)
+
+		for _, node := range query.Search.Nodes {
+			pr := node.PullRequest
+			if !f.Match(pr.BaseRepository.NameWithOwner, pr.BaseRepository.IsPrivate) {
+				continue
+			}
+
+			for _, review := range pr.Reviews.Nodes {
+				if !strings.EqualFold(review.Author.Login, user) {
+					continue
+				}
+				add(newReview(review, pr.Id, pr.Title, pr.Url, pr.BaseRepository.Name))
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make(map[string]types.Review)
+	for _, review := range byPr {
+		reviews[review.Id] = review
+	}
+
+	return reviews, nil
+}
+
+func newReview(review types.ReviewNode, prId string, prTitle string, prUrl string, repoName string) types.Review {
+	return types.Review{
+		Id:          review.Id,
+		RepoName:    repoName,
+		Title:       fmt.Sprintf("Review: %s", prTitle),
+		Body:        review.Body,
+		Url:         review.Url,
+		State:       review.State,
+		SubmittedAt: review.SubmittedAt,
+		PrId:        prId,
+		PrTitle:     prTitle,
+		PrUrl:       prUrl,
+	}
+}
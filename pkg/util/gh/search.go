@@ -0,0 +1,132 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/filter"
+	"github.com/ffalor/credit/pkg/util/types"
+	"github.com/shurcooL/githubv4"
+)
+
+// searchLimit is the maximum number of results github returns for a single search
+const searchLimit = 1000
+
+// searchEpoch is used as the start of open ended ranges that have to be split, nothing on
+// github predates it
+var searchEpoch = time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// search calls handle with every page of results of the search built by buildQuery for dateRange.
+// When the search has more results than github returns, the range is split into smaller windows
+// that are searched one after another. newQuery returns an empty query to decode a page into,
+// name describes the results in progress reports.
+func (g *Gh) search(ctx context.Context, name string, dateRange daterange.Range, buildQuery func(daterange.Range) string, newQuery func() types.SearchQuery, handle func(types.SearchQuery)) error {
+	variables := map[string]interface{}{
+		"query":        githubv4.String(buildQuery(dateRange)),
+		"searchCursor": (*githubv4.String)(nil),
+	}
+
+	firstPage := true
+	fetched := 0
+
+	for {
+		query := newQuery()
+
+		err := g.Client.Query(ctx, query, variables)
+		if err != nil {
+			return err
+		}
+
+		pageInfo, issueCount := query.Page()
+		g.rateLimit = query.Rate()
+
+		if firstPage && issueCount > searchLimit {
+			if first, second, ok := split(dateRange); ok {
+				if err := g.search(ctx, name, first, buildQuery, newQuery, handle); err != nil {
+					return err
+				}
+				return g.search(ctx, name, second, buildQuery, newQuery, handle)
+			}
+
+			g.report(Progress{Warning: fmt.Sprintf("%s %s has %d results, github search only returns the first %d, the rest are left out", name, dateRange, issueCount, searchLimit)})
+		}
+
+		firstPage = false
+		fetched += query.Results()
+		handle(query)
+
+		g.report(Progress{
+			Message: fmt.Sprintf("Fetching %s %s", name, dateRange),
+			Fetched: fetched,
+			Total:   issueCount,
+		})
+
+		if err := g.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		if !pageInfo.HasNextPage {
+			return nil
+		}
+
+		variables["searchCursor"] = githubv4.NewString(githubv4.String(pageInfo.EndCursor))
+	}
+}
+
+// scoped narrows the search query to the repositories matched by f
+func scoped(query string, f filter.Filter) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", query, f.Qualifiers()))
+}
+
+// waitForRateLimit waits for the rate limit to reset when the budget is used up
+func (g *Gh) waitForRateLimit(ctx context.Context) error {
+	if g.rateLimit.Remaining > g.rateLimit.Cost {
+		return nil
+	}
+
+	resetAt, err := time.Parse(time.RFC3339, g.rateLimit.ResetAt)
+	if err != nil {
+		return nil
+	}
+
+	wait := time.Until(resetAt)
+	if wait <= 0 {
+		return nil
+	}
+
+	g.report(Progress{Message: fmt.Sprintf("Rate limit exhausted, waiting %s for it to reset", wait.Round(time.Second))})
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// split divides dateRange into two halves, ok is false if the range is a single day
+func split(dateRange daterange.Range) (first daterange.Range, second daterange.Range, ok bool) {
+	from, to := dateRange.From, dateRange.To
+	if from.IsZero() {
+		from = searchEpoch
+	}
+	if to.IsZero() {
+		now := time.Now().UTC()
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	days := int(to.Sub(from).Hours() / 24)
+	if days < 1 {
+		return daterange.Range{}, daterange.Range{}, false
+	}
+
+	middle := from.AddDate(0, 0, days/2)
+
+	return daterange.Range{From: from, To: middle}, daterange.Range{From: middle.AddDate(0, 0, 1), To: to}, true
+}
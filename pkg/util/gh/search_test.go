@@ -0,0 +1,148 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/types"
+	"github.com/shurcooL/githubv4"
+)
+
+func day(value string) time.Time {
+	t, err := time.Parse(daterange.DateFormat, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name       string
+		dateRange  daterange.Range
+		wantFirst  string
+		wantSecond string
+		wantOk     bool
+	}{
+		{
+			name:       "even days",
+			dateRange:  daterange.Range{From: day("2024-01-01"), To: day("2024-01-05")},
+			wantFirst:  "2024-01-01..2024-01-03",
+			wantSecond: "2024-01-04..2024-01-05",
+			wantOk:     true,
+		},
+		{
+			name:       "two days",
+			dateRange:  daterange.Range{From: day("2024-01-01"), To: day("2024-01-02")},
+			wantFirst:  "2024-01-01..2024-01-01",
+			wantSecond: "2024-01-02..2024-01-02",
+			wantOk:     true,
+		},
+		{
+			name:       "across a month",
+			dateRange:  daterange.Range{From: day("2024-01-30"), To: day("2024-02-02")},
+			wantFirst:  "2024-01-30..2024-01-31",
+			wantSecond: "2024-02-01..2024-02-02",
+			wantOk:     true,
+		},
+		{
+			name:       "open start begins at the search epoch",
+			dateRange:  daterange.Range{To: day("2008-01-03")},
+			wantFirst:  "2008-01-01..2008-01-02",
+			wantSecond: "2008-01-03..2008-01-03",
+			wantOk:     true,
+		},
+		{
+			name:      "single day cannot be split",
+			dateRange: daterange.Range{From: day("2024-01-01"), To: day("2024-01-01")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second, ok := split(tt.dateRange)
+			if ok != tt.wantOk {
+				t.Fatalf("split() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if first.String() != tt.wantFirst || second.String() != tt.wantSecond {
+				t.Errorf("split() = %s, %s, want %s, %s", first, second, tt.wantFirst, tt.wantSecond)
+			}
+		})
+	}
+}
+
+func TestSplitOpenEnd(t *testing.T) {
+	today := time.Now().UTC().Format(daterange.DateFormat)
+
+	_, second, ok := split(daterange.Range{From: day("2024-01-01")})
+	if !ok {
+		t.Fatal("split() ok = false, want true")
+	}
+	if got := second.To.Format(daterange.DateFormat); got != today {
+		t.Errorf("split() second ends %s, want today %s", got, today)
+	}
+}
+
+func TestSearchSplitsAndWarns(t *testing.T) {
+	// every day has more results than github search returns
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables struct {
+				Query string `json:"query"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Variables.Query)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"rateLimit":{"remaining":5000},"search":{"issueCount":1500,"pageInfo":{"hasNextPage":false},"nodes":[]}}}`))
+	}))
+	defer srv.Close()
+
+	var warnings []string
+	g := &Gh{
+		Client: githubv4.NewEnterpriseClient(srv.URL, http.DefaultClient),
+		OnProgress: func(progress Progress) {
+			if progress.Warning != "" {
+				warnings = append(warnings, progress.Warning)
+			}
+		},
+	}
+
+	dateRange := daterange.Range{From: day("2024-01-01"), To: day("2024-01-02")}
+	buildQuery := func(r daterange.Range) string { return "closed:" + r.Qualifier() }
+	newQuery := func() types.SearchQuery { return &types.IssueQuery{} }
+
+	if err := g.search(context.Background(), "issues", dateRange, buildQuery, newQuery, func(types.SearchQuery) {}); err != nil {
+		t.Fatalf("search() error = %v", err)
+	}
+
+	wantQueries := []string{
+		"closed:2024-01-01..2024-01-02",
+		"closed:2024-01-01..2024-01-01",
+		"closed:2024-01-02..2024-01-02",
+	}
+	if strings.Join(queries, "\n") != strings.Join(wantQueries, "\n") {
+		t.Errorf("queries = %q, want %q", queries, wantQueries)
+	}
+
+	// a single day can not be split any further, each one is reported as truncated
+	if len(warnings) != 2 {
+		t.Fatalf("warnings = %q, want one per day", warnings)
+	}
+	for _, warning := range warnings {
+		if !strings.Contains(warning, "1500 results") {
+			t.Errorf("warning %q does not mention the result count", warning)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ffalor/credit/pkg/util/types"
+	"github.com/shurcooL/githubv4"
+)
+
+// TeamMembers returns the logins of the members of team in org, including members of child teams
+func (g *Gh) TeamMembers(ctx context.Context, org string, team string) ([]string, error) {
+	variables := map[string]interface{}{
+		"org":    githubv4.String(org),
+		"team":   githubv4.String(team),
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	var logins []string
+	for {
+		var query types.TeamMembersQuery
+		if err := g.Client.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		g.rateLimit = query.RateLimit
+
+		if query.Organization.Team.Id == "" {
+			return nil, fmt.Errorf("team %s/%s not found or not visible with this token", org, team)
+		}
+
+		members := query.Organization.Team.Members
+		for _, member := range members.Nodes {
+			logins = append(logins, member.Login)
+		}
+
+		if !members.PageInfo.HasNextPage {
+			return logins, nil
+		}
+		variables["cursor"] = githubv4.NewString(githubv4.String(members.PageInfo.EndCursor))
+	}
+}
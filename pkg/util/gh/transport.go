@@ -0,0 +1,136 @@
+package gh
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRetries is the number of times a failed request is retried
+	maxRetries = 5
+	// baseBackoff is the wait before the first retry, doubled on every attempt
+	baseBackoff = time.Second
+	// maxBackoff caps the wait between retries when github does not say how long to wait
+	maxBackoff = time.Minute
+	// secondaryRateLimitBackoff is the minimum wait after hitting a secondary rate limit
+	secondaryRateLimitBackoff = time.Minute
+)
+
+// retryTransport retries requests that failed because of rate limits, server errors or network
+// errors, backing off with jitter and honouring Retry-After and X-RateLimit-Reset
+type retryTransport struct {
+	base    http.RoundTripper
+	onRetry func(wait time.Duration, reason string)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryReq := req.Clone(req.Context())
+		if body != nil {
+			retryReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := t.base.RoundTrip(retryReq)
+
+		wait, reason, retry := retryAfter(resp, err, attempt)
+		if !retry || attempt >= maxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if t.onRetry != nil {
+			t.onRetry(wait, reason)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter decides if a response should be retried and how long to wait before doing so
+func retryAfter(resp *http.Response, err error, attempt int) (time.Duration, string, bool) {
+	if err != nil {
+		return backoff(attempt), err.Error(), true
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return rateLimitWait(resp, backoff(attempt)), resp.Status, true
+	case resp.StatusCode >= 500:
+		return rateLimitWait(resp, backoff(attempt)), resp.Status, true
+	case resp.StatusCode == http.StatusForbidden:
+		if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return rateLimitWait(resp, backoff(attempt)), "rate limited", true
+		}
+
+		// secondary rate limits are only identifiable by the message, keep the body readable
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+
+		if strings.Contains(strings.ToLower(string(data)), "secondary rate limit") {
+			wait := backoff(attempt)
+			if wait < secondaryRateLimitBackoff {
+				wait = secondaryRateLimitBackoff
+			}
+			return wait, "secondary rate limit", true
+		}
+	}
+
+	return 0, "", false
+}
+
+// rateLimitWait returns how long github asked to wait through Retry-After or X-RateLimit-Reset,
+// falling back to fallback
+func rateLimitWait(resp *http.Response, fallback time.Duration) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return time.Until(date)
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0))
+		}
+	}
+
+	return fallback
+}
+
+// backoff returns an exponential backoff with full jitter for the attempt
+func backoff(attempt int) time.Duration {
+	wait := baseBackoff << attempt
+	if wait > maxBackoff || wait <= 0 {
+		wait = maxBackoff
+	}
+
+	return time.Duration(rand.Int63n(int64(wait))) + baseBackoff/2
+}
@@ -0,0 +1,220 @@
+package gh
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newResponse(status int, headers map[string]string, body string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+	return resp
+}
+
+func TestRetryAfter(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(30*time.Second).Unix(), 10)
+	date := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name      string
+		resp      *http.Response
+		err       error
+		wantRetry bool
+		// the wait has to fall within [wantMin, wantMax]
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "ok", resp: newResponse(http.StatusOK, nil, "")},
+		{name: "not found", resp: newResponse(http.StatusNotFound, nil, "")},
+		{name: "forbidden", resp: newResponse(http.StatusForbidden, nil, "Resource not accessible by integration")},
+		{
+			name:      "network error",
+			err:       errors.New("connection reset"),
+			wantRetry: true,
+			wantMin:   baseBackoff / 2,
+			wantMax:   baseBackoff + baseBackoff/2,
+		},
+		{
+			name:      "server error",
+			resp:      newResponse(http.StatusBadGateway, nil, ""),
+			wantRetry: true,
+			wantMin:   baseBackoff / 2,
+			wantMax:   baseBackoff + baseBackoff/2,
+		},
+		{
+			name:      "too many requests with retry after seconds",
+			resp:      newResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "7"}, ""),
+			wantRetry: true,
+			wantMin:   7 * time.Second,
+			wantMax:   7 * time.Second,
+		},
+		{
+			name:      "retry after date",
+			resp:      newResponse(http.StatusServiceUnavailable, map[string]string{"Retry-After": date}, ""),
+			wantRetry: true,
+			wantMin:   18 * time.Second,
+			wantMax:   20 * time.Second,
+		},
+		{
+			name:      "forbidden with retry after",
+			resp:      newResponse(http.StatusForbidden, map[string]string{"Retry-After": "3"}, ""),
+			wantRetry: true,
+			wantMin:   3 * time.Second,
+			wantMax:   3 * time.Second,
+		},
+		{
+			name:      "primary rate limit waits for the reset",
+			resp:      newResponse(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, ""),
+			wantRetry: true,
+			wantMin:   28 * time.Second,
+			wantMax:   30 * time.Second,
+		},
+		{
+			name:      "secondary rate limit",
+			resp:      newResponse(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`),
+			wantRetry: true,
+			wantMin:   secondaryRateLimitBackoff,
+			wantMax:   secondaryRateLimitBackoff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, _, retry := retryAfter(tt.resp, tt.err, 0)
+			if retry != tt.wantRetry {
+				t.Fatalf("retryAfter() retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if wait < tt.wantMin || wait > tt.wantMax {
+				t.Errorf("retryAfter() wait = %v, want between %v and %v", wait, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRetryAfterKeepsBody(t *testing.T) {
+	body := "Resource not accessible by integration"
+	resp := newResponse(http.StatusForbidden, nil, body)
+
+	if _, _, retry := retryAfter(resp, nil, 0); retry {
+		t.Fatal("retryAfter() retry = true, want false")
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+	if string(data) != body {
+		t.Errorf("body = %q, want %q", data, body)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: baseBackoff},
+		{attempt: 1, max: 2 * baseBackoff},
+		{attempt: 3, max: 8 * baseBackoff},
+		{attempt: 10, max: maxBackoff},
+		{attempt: 100, max: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempt), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				wait := backoff(tt.attempt)
+				if wait < baseBackoff/2 || wait >= tt.max+baseBackoff/2 {
+					t.Fatalf("backoff(%d) = %v, want within [%v, %v)", tt.attempt, wait, baseBackoff/2, tt.max+baseBackoff/2)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		bodies = append(bodies, string(data))
+		attempt := len(bodies)
+		mu.Unlock()
+
+		if attempt < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var reasons []string
+	client := &http.Client{Transport: &retryTransport{
+		base: http.DefaultTransport,
+		onRetry: func(wait time.Duration, reason string) {
+			if wait != 0 {
+				t.Errorf("wait = %v, want the Retry-After of 0", wait)
+			}
+			reasons = append(reasons, reason)
+		},
+	}}
+
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"query":"{viewer{login}}"}`))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(reasons) != 2 {
+		t.Errorf("retried %d times, want 2", len(reasons))
+	}
+	// the body is replayed on every attempt
+	for i, body := range bodies {
+		if body != `{"query":"{viewer{login}}"}` {
+			t.Errorf("attempt %d body = %q", i, body)
+		}
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	if attempts != maxRetries+1 {
+		t.Errorf("attempts = %d, want %d", attempts, maxRetries+1)
+	}
+}
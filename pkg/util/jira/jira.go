@@ -0,0 +1,310 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/record"
+)
+
+// bulkLimit is the maximum number of issues accepted by a single issue/bulk request
+const bulkLimit = 50
+
+type Client struct {
+	BaseURL    string
+	Email      string
+	Token      string
+	APIVersion int
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Jira REST client, email may be empty to authenticate with token as a
+// personal access token instead of basic auth
+func NewClient(baseURL string, email string, token string, apiVersion int) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Email:      email,
+		Token:      token,
+		APIVersion: apiVersion,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// IssueUpdate is a single issue in an issue/bulk request
+type IssueUpdate struct {
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// BulkRequest is the body of an issue/bulk request
+type BulkRequest struct {
+	IssueUpdates []IssueUpdate `json:"issueUpdates"`
+}
+
+// CreatedIssue is an issue created by an issue/bulk request
+type CreatedIssue struct {
+	Id   string `json:"id"`
+	Key  string `json:"key"`
+	Self string `json:"self"`
+}
+
+// BulkError describes why an issue in an issue/bulk request was not created
+type BulkError struct {
+	Status        int `json:"status"`
+	ElementErrors struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	} `json:"elementErrors"`
+	FailedElementNumber int `json:"failedElementNumber"`
+}
+
+func (e BulkError) Error() string {
+	messages := append([]string{}, e.ElementErrors.ErrorMessages...)
+	for field, message := range e.ElementErrors.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, message))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// ResponseError is returned when the REST api responds with a non success status
+type ResponseError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("jira %s %s: %s: %s", e.Method, e.Path, e.Status, strings.TrimSpace(string(e.Body)))
+}
+
+type bulkResponse struct {
+	Issues []CreatedIssue `json:"issues"`
+	Errors []BulkError    `json:"errors"`
+}
+
+// BulkRequests splits issues into issue/bulk request bodies of at most 50 issues
+func BulkRequests(issues []IssueUpdate) []BulkRequest {
+	var requests []BulkRequest
+
+	for start := 0; start < len(issues); start += bulkLimit {
+		end := start + bulkLimit
+		if end > len(issues) {
+			end = len(issues)
+		}
+		requests = append(requests, BulkRequest{IssueUpdates: issues[start:end]})
+	}
+
+	return requests
+}
+
+// BulkCreate creates issues through the issue/bulk endpoint.
+// The returned slices are indexed like issues, created[i] is nil when issues[i] failed and
+// failed[i] holds the reason.
+func (c *Client) BulkCreate(ctx context.Context, issues []IssueUpdate) ([]*CreatedIssue, []error, error) {
+	created := make([]*CreatedIssue, len(issues))
+	failed := make([]error, len(issues))
+
+	for i, request := range BulkRequests(issues) {
+		offset := i * bulkLimit
+
+		var response bulkResponse
+		err := c.do(ctx, http.MethodPost, "issue/bulk", request, &response)
+
+		// issue/bulk responds with 400 when every issue failed, the reasons are in the body
+		var respErr *ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusBadRequest &&
+			json.Unmarshal(respErr.Body, &response) == nil && len(response.Errors) > 0 {
+			err = nil
+		}
+
+		if err != nil {
+			return created, failed, err
+		}
+
+		for _, bulkErr := range response.Errors {
+			if bulkErr.FailedElementNumber < 0 || bulkErr.FailedElementNumber >= len(request.IssueUpdates) {
+				continue
+			}
+			failed[offset+bulkErr.FailedElementNumber] = bulkErr
+		}
+
+		// created issues are returned in request order, skipping the failed ones
+		next := 0
+		for j := range request.IssueUpdates {
+			if failed[offset+j] != nil {
+				continue
+			}
+			if next >= len(response.Issues) {
+				break
+			}
+			issue := response.Issues[next]
+			created[offset+j] = &issue
+			next++
+		}
+	}
+
+	return created, failed, nil
+}
+
+// do sends a request to the REST api and decodes the json response into out
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/rest/api/%d/%s", c.BaseURL, c.APIVersion, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.Email != "" {
+		req.SetBasicAuth(c.Email, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return &ResponseError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       data,
+		}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+// description returns a description field value for the api version, plain text for v2 and an
+// Atlassian Document Format document for v3
+func description(text string, apiVersion int) interface{} {
+	if apiVersion < 3 {
+		return text
+	}
+
+	var content []interface{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		node := map[string]interface{}{"type": "paragraph", "content": []interface{}{}}
+		if paragraph != "" {
+			node["content"] = []interface{}{
+				map[string]interface{}{"type": "text", "text": paragraph},
+			}
+		}
+		content = append(content, node)
+	}
+
+	return map[string]interface{}{
+		"type":    "doc",
+		"version": 1,
+		"content": content,
+	}
+}
+
+// NewIssueUpdate builds the fields used to create a Jira issue for a credit record
+func NewIssueUpdate(r record.Record, cfg config.JiraConfig) (IssueUpdate, error) {
+	issueType, err := r.Field("type", cfg.IssueTypes)
+	if err != nil {
+		return IssueUpdate{}, err
+	}
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": cfg.Project},
+		"summary":     r.Title,
+		"description": description(r.Description, cfg.APIVersion),
+		"issuetype":   map[string]string{"name": issueType[0]},
+	}
+
+	if labels, _ := r.Field("labels", cfg.IssueTypes); len(labels) > 0 {
+		fields["labels"] = labels
+	}
+
+	if r.Epic != "" {
+		fields["parent"] = map[string]string{"key": r.Epic}
+	}
+
+	// users are assigned by account id on Jira Cloud and by username on Jira Server
+	if user, ok := cfg.Users[r.Assignee]; ok {
+		switch {
+		case user.AccountId != "":
+			fields["assignee"] = map[string]string{"accountId": user.AccountId}
+		case user.Name != "":
+			fields["assignee"] = map[string]string{"name": user.Name}
+		}
+	}
+
+	// custom fields from the column mapping are sent as plain values
+	for _, column := range cfg.Columns {
+		if !strings.HasPrefix(column.JiraField, "customfield_") {
+			continue
+		}
+
+		value := column.Value
+		if column.Field != "" {
+			values, err := r.Field(column.Field, cfg.IssueTypes)
+			if err != nil {
+				return IssueUpdate{}, err
+			}
+			value = strings.Join(values, " ")
+		}
+
+		if value == "" {
+			continue
+		}
+
+		if number, err := strconv.ParseFloat(value, 64); err == nil && isNumberField(column.Field) {
+			fields[column.JiraField] = number
+			continue
+		}
+
+		fields[column.JiraField] = value
+	}
+
+	return IssueUpdate{Fields: fields}, nil
+}
+
+// isNumberField reports whether the record field holds a number, which Jira number fields
+// expect unquoted
+func isNumberField(field string) bool {
+	switch field {
+	case "points", "additions", "deletions", "files", "commits", "reviews", "comments":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/record"
+)
+
+// bulkServer is a stand-in for the issue/bulk endpoint. respond builds the response for the
+// n-th request from the decoded request body.
+type bulkServer struct {
+	mu       sync.Mutex
+	paths    []string
+	requests []BulkRequest
+	bodies   []map[string]interface{}
+	respond  func(n int, request BulkRequest) (int, bulkResponse)
+}
+
+func (s *bulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var raw map[string]interface{}
+	var request BulkRequest
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, _ := json.Marshal(raw)
+	_ = json.Unmarshal(data, &request)
+
+	s.mu.Lock()
+	n := len(s.requests)
+	s.paths = append(s.paths, r.URL.Path)
+	s.requests = append(s.requests, request)
+	s.bodies = append(s.bodies, raw)
+	s.mu.Unlock()
+
+	status, response := s.respond(n, request)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// createAll responds to every request with all issues created, keyed by request and index
+func createAll(n int, request BulkRequest) (int, bulkResponse) {
+	var response bulkResponse
+	for i := range request.IssueUpdates {
+		response.Issues = append(response.Issues, CreatedIssue{Key: fmt.Sprintf("CR-%d-%d", n, i)})
+	}
+	return http.StatusCreated, response
+}
+
+func newIssues(count int) []IssueUpdate {
+	issues := make([]IssueUpdate, count)
+	for i := range issues {
+		issues[i] = IssueUpdate{Fields: map[string]interface{}{"summary": fmt.Sprintf("issue %d", i)}}
+	}
+	return issues
+}
+
+func bulkError(element int, message string) BulkError {
+	err := BulkError{Status: http.StatusBadRequest, FailedElementNumber: element}
+	err.ElementErrors.Errors = map[string]string{"summary": message}
+	return err
+}
+
+func TestBulkCreateBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		issues  int
+		batches []int
+	}{
+		{name: "single batch", issues: 3, batches: []int{3}},
+		{name: "exactly the limit", issues: 50, batches: []int{50}},
+		{name: "several batches", issues: 120, batches: []int{50, 50, 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &bulkServer{respond: createAll}
+			srv := httptest.NewServer(stub)
+			defer srv.Close()
+
+			client := NewClient(srv.URL, "user@example.com", "token", 2)
+			created, failed, err := client.BulkCreate(context.Background(), newIssues(tt.issues))
+			if err != nil {
+				t.Fatalf("BulkCreate() error = %v", err)
+			}
+
+			var batches []int
+			for _, request := range stub.requests {
+				batches = append(batches, len(request.IssueUpdates))
+			}
+			if !reflect.DeepEqual(batches, tt.batches) {
+				t.Errorf("batch sizes = %v, want %v", batches, tt.batches)
+			}
+
+			for i := range created {
+				want := fmt.Sprintf("CR-%d-%d", i/bulkLimit, i%bulkLimit)
+				if failed[i] != nil || created[i] == nil || created[i].Key != want {
+					t.Errorf("issue %d: created = %v, failed = %v, want key %s", i, created[i], failed[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkCreatePartialFailure(t *testing.T) {
+	// the first batch fails on its second issue, every issue of the second batch fails and
+	// the batch is rejected with 400
+	stub := &bulkServer{respond: func(n int, request BulkRequest) (int, bulkResponse) {
+		if n == 0 {
+			var response bulkResponse
+			for i := range request.IssueUpdates {
+				if i == 1 {
+					continue
+				}
+				response.Issues = append(response.Issues, CreatedIssue{Key: fmt.Sprintf("CR-%d", i)})
+			}
+			response.Errors = []BulkError{bulkError(1, "summary is required")}
+			return http.StatusCreated, response
+		}
+
+		var response bulkResponse
+		for i := range request.IssueUpdates {
+			response.Errors = append(response.Errors, bulkError(i, "bad issue type"))
+		}
+		return http.StatusBadRequest, response
+	}}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "", "token", 2)
+	created, failed, err := client.BulkCreate(context.Background(), newIssues(53))
+	if err != nil {
+		t.Fatalf("BulkCreate() error = %v", err)
+	}
+
+	tests := []struct {
+		index   int
+		wantKey string
+		wantErr string
+	}{
+		{index: 0, wantKey: "CR-0"},
+		{index: 1, wantErr: "summary: summary is required"},
+		{index: 2, wantKey: "CR-2"},
+		{index: 49, wantKey: "CR-49"},
+		{index: 50, wantErr: "summary: bad issue type"},
+		{index: 52, wantErr: "summary: bad issue type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.index), func(t *testing.T) {
+			if tt.wantErr != "" {
+				if created[tt.index] != nil {
+					t.Errorf("created = %v, want nil", created[tt.index])
+				}
+				if failed[tt.index] == nil || failed[tt.index].Error() != tt.wantErr {
+					t.Errorf("failed = %v, want %q", failed[tt.index], tt.wantErr)
+				}
+				return
+			}
+
+			if failed[tt.index] != nil {
+				t.Errorf("failed = %v, want nil", failed[tt.index])
+			}
+			if created[tt.index] == nil || created[tt.index].Key != tt.wantKey {
+				t.Errorf("created = %v, want key %s", created[tt.index], tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestBulkCreateServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "", "token", 2)
+	_, _, err := client.BulkCreate(context.Background(), newIssues(1))
+
+	respErr, ok := err.(*ResponseError)
+	if !ok || respErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("BulkCreate() error = %v, want a 401 ResponseError", err)
+	}
+}
+
+func TestBulkCreateDescription(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion int
+		want       interface{}
+	}{
+		{
+			name:       "v2 plain text",
+			apiVersion: 2,
+			want:       "Fix the thing\n\nURL: https://github.com/o/r/pull/1",
+		},
+		{
+			name:       "v3 atlassian document format",
+			apiVersion: 3,
+			want: map[string]interface{}{
+				"type":    "doc",
+				"version": float64(1),
+				"content": []interface{}{
+					map[string]interface{}{"type": "paragraph", "content": []interface{}{
+						map[string]interface{}{"type": "text", "text": "Fix the thing"},
+					}},
+					map[string]interface{}{"type": "paragraph", "content": []interface{}{}},
+					map[string]interface{}{"type": "paragraph", "content": []interface{}{
+						map[string]interface{}{"type": "text", "text": "URL: https://github.com/o/r/pull/1"},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &bulkServer{respond: createAll}
+			srv := httptest.NewServer(stub)
+			defer srv.Close()
+
+			cfg := config.JiraConfig{Project: "CR", APIVersion: tt.apiVersion, IssueTypes: map[string]string{"pr": "Task"}}
+			r := record.Record{Kind: "pr", Title: "Fix", Description: "Fix the thing\n\nURL: https://github.com/o/r/pull/1"}
+
+			update, err := NewIssueUpdate(r, cfg)
+			if err != nil {
+				t.Fatalf("NewIssueUpdate() error = %v", err)
+			}
+
+			client := NewClient(srv.URL, "", "token", tt.apiVersion)
+			if _, _, err := client.BulkCreate(context.Background(), []IssueUpdate{update}); err != nil {
+				t.Fatalf("BulkCreate() error = %v", err)
+			}
+
+			if want := fmt.Sprintf("/rest/api/%d/issue/bulk", tt.apiVersion); stub.paths[0] != want {
+				t.Errorf("path = %s, want %s", stub.paths[0], want)
+			}
+
+			issueUpdates := stub.bodies[0]["issueUpdates"].([]interface{})
+			fields := issueUpdates[0].(map[string]interface{})["fields"].(map[string]interface{})
+			if got := fields["description"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("description = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
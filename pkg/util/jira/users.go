@@ -0,0 +1,91 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ffalor/credit/pkg/util/config"
+)
+
+// UserSearcher finds Jira users matching a query, Client searches the Jira user search API
+type UserSearcher interface {
+	SearchUsers(ctx context.Context, query string) ([]config.JiraUser, error)
+}
+
+// user is a user returned by the user search API, Jira Cloud sets accountId and Jira Server name
+type user struct {
+	AccountId    string `json:"accountId"`
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+}
+
+// SearchUsers returns the users whose name or email matches query. Jira Cloud is searched with
+// the query parameter, Jira Server, which is used with a personal access token, by username.
+func (c *Client) SearchUsers(ctx context.Context, query string) ([]config.JiraUser, error) {
+	param := "query"
+	if c.Email == "" {
+		param = "username"
+	}
+
+	var found []user
+	if err := c.do(ctx, "GET", fmt.Sprintf("user/search?%s=%s", param, url.QueryEscape(query)), nil, &found); err != nil {
+		return nil, err
+	}
+
+	users := make([]config.JiraUser, len(found))
+	for i, u := range found {
+		users[i] = config.JiraUser{
+			AccountId:   u.AccountId,
+			Name:        u.Name,
+			Email:       u.EmailAddress,
+			DisplayName: u.DisplayName,
+		}
+	}
+
+	return users, nil
+}
+
+// MapUsers returns the Jira user of every login, taken from users or, when searcher is not nil,
+// the single Jira user found by searching for the login. unmapped lists the logins without one.
+func MapUsers(ctx context.Context, logins []string, users map[string]config.JiraUser, searcher UserSearcher) (mapped map[string]config.JiraUser, unmapped []string, err error) {
+	mapped = make(map[string]config.JiraUser)
+
+	for _, login := range logins {
+		if user, ok := lookup(users, login); ok {
+			mapped[login] = user
+			continue
+		}
+
+		if searcher != nil {
+			found, err := searcher.SearchUsers(ctx, login)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not search Jira users for %s: %w", login, err)
+			}
+			// several matches are ambiguous, the login has to be mapped explicitly
+			if len(found) == 1 {
+				mapped[login] = found[0]
+				continue
+			}
+		}
+
+		unmapped = append(unmapped, login)
+	}
+
+	return mapped, unmapped, nil
+}
+
+// lookup finds login in users ignoring case, GitHub logins are case insensitive
+func lookup(users map[string]config.JiraUser, login string) (config.JiraUser, bool) {
+	if user, ok := users[login]; ok {
+		return user, true
+	}
+	for l, user := range users {
+		if strings.EqualFold(l, login) {
+			return user, true
+		}
+	}
+	return config.JiraUser{}, false
+}
@@ -0,0 +1,135 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/config"
+)
+
+// userSearchServer is a stand-in for the user/search endpoint answering with the users listed
+// for the searched query or username
+func userSearchServer(t *testing.T, results map[string][]user) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/3/user/search" {
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query().Get("query")
+		if query == "" {
+			query = "username:" + r.URL.Query().Get("username")
+		}
+		queries = append(queries, query)
+
+		found, ok := results[query]
+		if !ok {
+			found = []user{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(found)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &queries
+}
+
+func TestMapUsersSearch(t *testing.T) {
+	srv, queries := userSearchServer(t, map[string][]user{
+		"octocat": {
+			{AccountId: "5b10ac8d82e05b22cc7d4ef5", EmailAddress: "octocat@example.com", DisplayName: "Octo Cat"},
+		},
+		"hubot": {
+			{AccountId: "1", EmailAddress: "hubot@example.com"},
+			{AccountId: "2", EmailAddress: "hubot@example.org"},
+		},
+	})
+
+	client := NewClient(srv.URL, "user@example.com", "token", 3)
+	configured := map[string]config.JiraUser{"Mona": {AccountId: "configured"}}
+
+	mapped, unmapped, err := MapUsers(context.Background(), []string{"octocat", "hubot", "ghost", "mona"}, configured, client)
+	if err != nil {
+		t.Fatalf("MapUsers() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		login string
+		want  *config.JiraUser
+	}{
+		{
+			name:  "single match maps email to account id",
+			login: "octocat",
+			want:  &config.JiraUser{AccountId: "5b10ac8d82e05b22cc7d4ef5", Email: "octocat@example.com", DisplayName: "Octo Cat"},
+		},
+		{name: "ambiguous match is unmapped", login: "hubot"},
+		{name: "no match is unmapped", login: "ghost"},
+		{name: "configured user ignores case", login: "mona", want: &config.JiraUser{AccountId: "configured"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, ok := mapped[tt.login]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("mapped[%s] = %+v, want unmapped", tt.login, user)
+				}
+				return
+			}
+			if !ok || user != *tt.want {
+				t.Errorf("mapped[%s] = %+v, want %+v", tt.login, user, *tt.want)
+			}
+		})
+	}
+
+	if want := []string{"hubot", "ghost"}; !reflect.DeepEqual(unmapped, want) {
+		t.Errorf("unmapped = %v, want %v", unmapped, want)
+	}
+
+	// configured users are not searched for
+	if want := []string{"octocat", "hubot", "ghost"}; !reflect.DeepEqual(*queries, want) {
+		t.Errorf("searched %v, want %v", *queries, want)
+	}
+}
+
+func TestSearchUsersServer(t *testing.T) {
+	srv, queries := userSearchServer(t, map[string][]user{
+		"username:octocat": {{Name: "octocat", EmailAddress: "octocat@example.com"}},
+	})
+
+	// without an email the client authenticates with a personal access token against Jira
+	// Server, which searches by username
+	client := NewClient(srv.URL, "", "token", 3)
+	users, err := client.SearchUsers(context.Background(), "octocat")
+	if err != nil {
+		t.Fatalf("SearchUsers() error = %v", err)
+	}
+
+	want := []config.JiraUser{{Name: "octocat", Email: "octocat@example.com"}}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("SearchUsers() = %+v, want %+v", users, want)
+	}
+	if want := []string{"username:octocat"}; !reflect.DeepEqual(*queries, want) {
+		t.Errorf("searched %v, want %v", *queries, want)
+	}
+}
+
+func TestMapUsersSearchError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "user@example.com", "token", 3)
+	if _, _, err := MapUsers(context.Background(), []string{"octocat"}, nil, client); err == nil {
+		t.Error("MapUsers() error = nil, want the search error")
+	}
+}
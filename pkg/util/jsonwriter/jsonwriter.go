@@ -0,0 +1,355 @@
+package jsonwriter
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// SchemaVersion is the version of the exported schema. It is incremented whenever a field is
+// removed or changes meaning, adding fields does not change the version.
+//
+// Version 2: user names the exported users instead of being a single login, and the user of
+// each json line is the user the item is credited to.
+const SchemaVersion = 2
+
+// Export is the document written in json format
+type Export struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// User is a display name for the exported users, a login, a comma separated list of logins or
+	// org-team, rather than a login to look up. Each item has the login it is credited to as
+	// assignee.
+	User        string     `json:"user"`
+	GeneratedAt time.Time  `json:"generatedAt"`
+	MergedPrs   []MergedPr `json:"mergedPrs"`
+	Issues      []Issue    `json:"issues"`
+	Reviews     []Review   `json:"reviews"`
+	// Contributions are commits and created repositories, only found with the contributions source
+	Contributions []Contribution `json:"contributions"`
+}
+
+// MergedPr is a merged PR in the exported schema
+type MergedPr struct {
+	Id              string   `json:"id"`
+	Repo            string   `json:"repo"`
+	Title           string   `json:"title"`
+	Body            string   `json:"body"`
+	Url             string   `json:"url"`
+	CreatedAt       string   `json:"createdAt"`
+	MergedAt        string   `json:"mergedAt"`
+	Epic            string   `json:"epic"`
+	ClosingIssueIds []string `json:"closingIssueIds"`
+	Additions       int      `json:"additions"`
+	Deletions       int      `json:"deletions"`
+	ChangedFiles    int      `json:"changedFiles"`
+	Commits         int      `json:"commits"`
+	Reviews         int      `json:"reviews"`
+	Comments        int      `json:"comments"`
+	// FoldedIssues are the closed issues combined with the PR by --fold pr
+	FoldedIssues []Issue `json:"foldedIssues"`
+	Assignee     string  `json:"assignee"`
+}
+
+// Issue is a closed issue in the exported schema
+type Issue struct {
+	Id          string   `json:"id"`
+	Repo        string   `json:"repo"`
+	Title       string   `json:"title"`
+	Body        string   `json:"body"`
+	Url         string   `json:"url"`
+	Epic        string   `json:"epic"`
+	ClosedAt    string   `json:"closedAt"`
+	StateReason string   `json:"stateReason"`
+	Milestone   string   `json:"milestone"`
+	Labels      []string `json:"labels"`
+	// Assignees are the issue's assignees on github, Assignee is the user it is credited to
+	Assignees []string         `json:"assignees"`
+	ClosedBy  []PullRequestRef `json:"closedBy"`
+	// Attribution is how the issue is credited to the assignee e.g. author or closer
+	Attribution string `json:"attribution"`
+	// FoldedPrs are the PRs that closed the issue combined with it by --fold issue
+	FoldedPrs []MergedPr `json:"foldedPrs"`
+	Assignee  string     `json:"assignee"`
+}
+
+// PullRequestRef is a PR that closed an issue in the exported schema
+type PullRequestRef struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+	Url   string `json:"url"`
+}
+
+// Review is a review given on someone else's PR in the exported schema
+type Review struct {
+	Id          string `json:"id"`
+	Repo        string `json:"repo"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	Url         string `json:"url"`
+	Epic        string `json:"epic"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submittedAt"`
+	PrId        string `json:"prId"`
+	PrTitle     string `json:"prTitle"`
+	PrUrl       string `json:"prUrl"`
+	Assignee    string `json:"assignee"`
+}
+
+// Contribution is a commits or created repository contribution in the exported schema
+type Contribution struct {
+	Id         string `json:"id"`
+	Kind       string `json:"kind"`
+	Repo       string `json:"repo"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	Url        string `json:"url"`
+	Epic       string `json:"epic"`
+	OccurredAt string `json:"occurredAt"`
+	Assignee   string `json:"assignee"`
+}
+
+// LineHeader starts every line written in jsonl format, followed by the fields of a MergedPr
+// when kind is pr, an Issue when kind is issue, a Review when kind is review or a Contribution
+// when kind is commits or repository
+type LineHeader struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Kind          string `json:"kind"`
+	// User is the user the item is credited to
+	User string `json:"user"`
+}
+
+type mergedPrLine struct {
+	LineHeader
+	MergedPr
+}
+
+type issueLine struct {
+	LineHeader
+	Issue
+}
+
+type reviewLine struct {
+	LineHeader
+	Review
+}
+
+// contributionLine carries the kind in the header only
+type contributionLine struct {
+	LineHeader
+	Id         string `json:"id"`
+	Repo       string `json:"repo"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	Url        string `json:"url"`
+	Epic       string `json:"epic"`
+	OccurredAt string `json:"occurredAt"`
+	Assignee   string `json:"assignee"`
+}
+
+type Writer struct {
+	lines bool
+}
+
+// NewWriter returns a writer for a single json document, or one json object per line when
+// lines is true
+func NewWriter(lines bool) *Writer {
+	return &Writer{
+		lines: lines,
+	}
+}
+
+// Write exports all credit into out as a json document, or json lines in lines mode
+func (w *Writer) Write(out io.Writer, user string, credit types.Credit) error {
+	export := NewExport(user, credit)
+
+	encoder := json.NewEncoder(out)
+
+	if !w.lines {
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+	}
+
+	for _, pr := range export.MergedPrs {
+		line := mergedPrLine{LineHeader{SchemaVersion: SchemaVersion, Kind: "pr", User: pr.Assignee}, pr}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	for _, issue := range export.Issues {
+		line := issueLine{LineHeader{SchemaVersion: SchemaVersion, Kind: "issue", User: issue.Assignee}, issue}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	for _, review := range export.Reviews {
+		line := reviewLine{LineHeader{SchemaVersion: SchemaVersion, Kind: "review", User: review.Assignee}, review}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range export.Contributions {
+		line := contributionLine{
+			LineHeader: LineHeader{SchemaVersion: SchemaVersion, Kind: c.Kind, User: c.Assignee},
+			Id:         c.Id,
+			Repo:       c.Repo,
+			Title:      c.Title,
+			Body:       c.Body,
+			Url:        c.Url,
+			Epic:       c.Epic,
+			OccurredAt: c.OccurredAt,
+			Assignee:   c.Assignee,
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewExport converts credit into the exported schema, ordered by repository and id
+func NewExport(user string, credit types.Credit) Export {
+	export := Export{
+		SchemaVersion: SchemaVersion,
+		User:          user,
+		GeneratedAt:   time.Now().UTC(),
+		MergedPrs:     []MergedPr{},
+		Issues:        []Issue{},
+		Reviews:       []Review{},
+		Contributions: []Contribution{},
+	}
+
+	for _, pr := range credit.MergedPrs {
+		export.MergedPrs = append(export.MergedPrs, newMergedPr(user, pr))
+	}
+
+	for _, issue := range credit.Issues {
+		export.Issues = append(export.Issues, newIssue(user, issue))
+	}
+
+	for _, review := range credit.Reviews {
+		export.Reviews = append(export.Reviews, Review{
+			Id:          review.Id,
+			Repo:        review.RepoName,
+			Title:       review.Title,
+			Body:        review.Body,
+			Url:         review.Url,
+			Epic:        review.Epic,
+			State:       review.State,
+			SubmittedAt: review.SubmittedAt,
+			PrId:        review.PrId,
+			PrTitle:     review.PrTitle,
+			PrUrl:       review.PrUrl,
+			Assignee:    types.Credited(review.User, user),
+		})
+	}
+
+	for _, contribution := range credit.Contributions {
+		export.Contributions = append(export.Contributions, Contribution{
+			Id:         contribution.Id,
+			Kind:       contribution.Kind,
+			Repo:       contribution.RepoName,
+			Title:      contribution.Title,
+			Body:       contribution.Body,
+			Url:        contribution.Url,
+			Epic:       contribution.Epic,
+			OccurredAt: contribution.OccurredAt,
+			Assignee:   types.Credited(contribution.User, user),
+		})
+	}
+
+	sort.Slice(export.MergedPrs, func(i, j int) bool {
+		return less(export.MergedPrs[i].Repo, export.MergedPrs[i].Id, export.MergedPrs[j].Repo, export.MergedPrs[j].Id)
+	})
+	sort.Slice(export.Issues, func(i, j int) bool {
+		return less(export.Issues[i].Repo, export.Issues[i].Id, export.Issues[j].Repo, export.Issues[j].Id)
+	})
+	sort.Slice(export.Reviews, func(i, j int) bool {
+		return less(export.Reviews[i].Repo, export.Reviews[i].Id, export.Reviews[j].Repo, export.Reviews[j].Id)
+	})
+	sort.Slice(export.Contributions, func(i, j int) bool {
+		return less(export.Contributions[i].Repo, export.Contributions[i].Id, export.Contributions[j].Repo, export.Contributions[j].Id)
+	})
+
+	return export
+}
+
+// newMergedPr converts a merged PR credited to user
+func newMergedPr(user string, pr types.MergedPr) MergedPr {
+	foldedIssues := []Issue{}
+	for _, issue := range pr.FoldedIssues {
+		foldedIssues = append(foldedIssues, newIssue(user, issue))
+	}
+
+	return MergedPr{
+		Id:              pr.Id,
+		Repo:            pr.RepoName,
+		Title:           pr.Title,
+		Body:            pr.Body,
+		Url:             pr.Url,
+		CreatedAt:       pr.CreatedAt,
+		MergedAt:        pr.MergedAt,
+		Epic:            pr.Epic,
+		ClosingIssueIds: nonNil(pr.ClosingIssueIds),
+		Additions:       pr.Metrics.Additions,
+		Deletions:       pr.Metrics.Deletions,
+		ChangedFiles:    pr.Metrics.ChangedFiles,
+		Commits:         pr.Metrics.Commits,
+		Reviews:         pr.Metrics.Reviews,
+		Comments:        pr.Metrics.Comments,
+		FoldedIssues:    foldedIssues,
+		Assignee:        types.Credited(pr.User, user),
+	}
+}
+
+// newIssue converts an issue credited to user
+func newIssue(user string, issue types.Issue) Issue {
+	closedBy := []PullRequestRef{}
+	for _, pr := range issue.ClosedBy {
+		closedBy = append(closedBy, PullRequestRef{Id: pr.Id, Title: pr.Title, Url: pr.Url})
+	}
+
+	foldedPrs := []MergedPr{}
+	for _, pr := range issue.FoldedPrs {
+		foldedPrs = append(foldedPrs, newMergedPr(user, pr))
+	}
+
+	return Issue{
+		Id:          issue.Id,
+		Repo:        issue.RepoName,
+		Title:       issue.Title,
+		Body:        issue.Body,
+		Url:         issue.Url,
+		Epic:        issue.Epic,
+		ClosedAt:    issue.ClosedAt,
+		StateReason: issue.StateReason,
+		Milestone:   issue.Milestone,
+		Labels:      nonNil(issue.Labels),
+		Assignees:   nonNil(issue.Assignees),
+		ClosedBy:    closedBy,
+		Attribution: issue.Attribution,
+		FoldedPrs:   foldedPrs,
+		Assignee:    types.Credited(issue.User, user),
+	}
+}
+
+func less(repoA, idA, repoB, idB string) bool {
+	if repoA != repoB {
+		return repoA < repoB
+	}
+	return idA < idB
+}
+
+// nonNil keeps empty lists as [] rather than null in the output
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
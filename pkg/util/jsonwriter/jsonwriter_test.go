@@ -0,0 +1,165 @@
+package jsonwriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// generatedAt matches the only field of the document that changes between runs
+var generatedAt = regexp.MustCompile(`"generatedAt": "[^"]*"`)
+
+// testCredit holds items of every kind in two repositories, keyed in an order that differs from
+// the exported one
+func testCredit() types.Credit {
+	credit := types.NewCredit()
+	credit.MergedPrs["PR_2"] = types.MergedPr{
+		Id:              "PR_2",
+		RepoName:        "o/b",
+		Title:           "Second",
+		Url:             "https://github.com/o/b/pull/2",
+		CreatedAt:       "2023-01-02T00:00:00Z",
+		MergedAt:        "2023-01-03T00:00:00Z",
+		ClosingIssueIds: []string{"I_1"},
+		Metrics:         types.PrMetrics{Additions: 10, Deletions: 2, ChangedFiles: 3, Commits: 1, Reviews: 1, Comments: 4, Fetched: true},
+	}
+	credit.MergedPrs["PR_1"] = types.MergedPr{
+		Id:        "PR_1",
+		RepoName:  "o/b",
+		Title:     "First",
+		Url:       "https://github.com/o/b/pull/1",
+		CreatedAt: "2023-01-01T00:00:00Z",
+		MergedAt:  "2023-01-02T00:00:00Z",
+		User:      "hubot",
+	}
+	credit.MergedPrs["PR_3"] = types.MergedPr{
+		Id:        "PR_3",
+		RepoName:  "o/a",
+		Title:     "Third",
+		Url:       "https://github.com/o/a/pull/3",
+		CreatedAt: "2023-01-04T00:00:00Z",
+		MergedAt:  "2023-01-05T00:00:00Z",
+	}
+	credit.Issues["I_1"] = types.Issue{
+		Id:          "I_1",
+		RepoName:    "o/b",
+		Title:       "Bug",
+		Url:         "https://github.com/o/b/issues/1",
+		ClosedAt:    "2023-01-03T00:00:00Z",
+		StateReason: "COMPLETED",
+		Labels:      []string{"bug"},
+		ClosedBy:    []types.PullRequestRef{{Id: "PR_2", Title: "Second", Url: "https://github.com/o/b/pull/2"}},
+		Attribution: "pr-author",
+	}
+	credit.Reviews["PRR_1"] = types.Review{
+		Id:          "PRR_1",
+		RepoName:    "o/a",
+		Title:       "Review of Fourth",
+		Url:         "https://github.com/o/a/pull/4#pullrequestreview-1",
+		State:       "APPROVED",
+		SubmittedAt: "2023-01-06T00:00:00Z",
+		PrId:        "PR_4",
+		PrTitle:     "Fourth",
+		PrUrl:       "https://github.com/o/a/pull/4",
+		User:        "hubot",
+	}
+	credit.Contributions["commits:octocat:o/a:2023-01-07"] = types.Contribution{
+		Id:         "commits:octocat:o/a:2023-01-07",
+		Kind:       types.ContributionCommits,
+		RepoName:   "o/a",
+		Title:      "2 commits to o/a",
+		Url:        "https://github.com/o/a/commits",
+		OccurredAt: "2023-01-07T00:00:00Z",
+	}
+	return credit
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  bool
+		golden string
+	}{
+		{name: "document", golden: "export.json"},
+		{name: "lines", lines: true, golden: "export.jsonl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := NewWriter(tt.lines).Write(&out, "octocat", testCredit()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			got := generatedAt.ReplaceAll(out.Bytes(), []byte(`"generatedAt": "0001-01-01T00:00:00Z"`))
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Write() output does not match %s, got\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestWriteGeneratedAt(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewWriter(false).Write(&out, "octocat", types.NewCredit()); err != nil {
+		t.Fatal(err)
+	}
+
+	var export Export
+	if err := json.Unmarshal(out.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.GeneratedAt.Location() != time.UTC || time.Since(export.GeneratedAt) > time.Minute {
+		t.Errorf("generatedAt = %v, want the current time in UTC", export.GeneratedAt)
+	}
+}
+
+func TestWriteLineHeaders(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewWriter(true).Write(&out, "octocat", testCredit()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LineHeader{
+		{SchemaVersion: 2, Kind: "pr", User: "octocat"},
+		{SchemaVersion: 2, Kind: "pr", User: "hubot"},
+		{SchemaVersion: 2, Kind: "pr", User: "octocat"},
+		{SchemaVersion: 2, Kind: "issue", User: "octocat"},
+		{SchemaVersion: 2, Kind: "review", User: "hubot"},
+		{SchemaVersion: 2, Kind: "commits", User: "octocat"},
+	}
+
+	decoder := json.NewDecoder(&out)
+	for i, w := range want {
+		var header LineHeader
+		if err := decoder.Decode(&header); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if header != w {
+			t.Errorf("line %d header = %+v, want %+v", i+1, header, w)
+		}
+	}
+	if decoder.More() {
+		t.Errorf("Write() wrote more than %d lines", len(want))
+	}
+}
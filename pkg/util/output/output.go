@@ -0,0 +1,136 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+)
+
+// Stdout is the output path that writes to stdout
+const Stdout = "-"
+
+// File is an export destination. Files are written to a temporary file next to the target that
+// replaces it on Commit, so an interrupted export never leaves a partial file behind.
+type File struct {
+	path      string
+	tmp       *os.File
+	committed bool
+}
+
+// Create opens path for writing, or stdout when path is "-".
+// An existing file is only replaced when force is set.
+func Create(path string, force bool) (*File, error) {
+	if path == Stdout {
+		return &File{path: path}, nil
+	}
+
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s already exists, use --force to overwrite it", path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{path: path, tmp: tmp}, nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	if f.tmp == nil {
+		return os.Stdout.Write(p)
+	}
+
+	return f.tmp.Write(p)
+}
+
+// Path returns the path being written, "-" for stdout
+func (f *File) Path() string {
+	return f.path
+}
+
+// IsStdout reports whether the file writes to stdout
+func (f *File) IsStdout() bool {
+	return f.tmp == nil
+}
+
+// Commit moves the written content into place
+func (f *File) Commit() error {
+	if f.tmp == nil || f.committed {
+		return nil
+	}
+
+	if err := f.tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.tmp.Name(), f.path); err != nil {
+		return err
+	}
+
+	f.committed = true
+	return nil
+}
+
+// Close discards the written content unless it was committed
+func (f *File) Close() error {
+	if f.tmp == nil || f.committed {
+		return nil
+	}
+
+	f.tmp.Close()
+	return os.Remove(f.tmp.Name())
+}
+
+// DefaultName returns the default export file name for user and dateRange,
+// e.g. credit-ffalor-2025-01-01_2025-03-31.csv. An open ended range ends today.
+func DefaultName(user string, dateRange daterange.Range, ext string) string {
+	from, to := "start", time.Now().Format(daterange.DateFormat)
+	if !dateRange.From.IsZero() {
+		from = dateRange.From.Format(daterange.DateFormat)
+	}
+	if !dateRange.To.IsZero() {
+		to = dateRange.To.Format(daterange.DateFormat)
+	}
+
+	return fmt.Sprintf("credit-%s-%s_%s.%s", user, from, to, ext)
+}
+
+// WriteFile calls write with the file at path, see Create, and commits it if write succeeds
+func WriteFile(path string, force bool, write func(io.Writer) error) error {
+	file, err := Create(path, force)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+
+	return file.Commit()
+}
+
+// Exists reports whether path is an existing file that can not be written without force
+func Exists(path string) bool {
+	if path == Stdout {
+		return false
+	}
+
+	_, err := os.Stat(path)
+	return err == nil
+}
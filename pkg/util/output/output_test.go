@@ -0,0 +1,151 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+)
+
+func TestWriteFile(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	tests := []struct {
+		name     string
+		existing string
+		force    bool
+		write    string
+		writeErr error
+		want     string
+		wantErr  bool
+	}{
+		{name: "new file", write: "new", want: "new"},
+		{name: "existing file without force", existing: "old", write: "new", want: "old", wantErr: true},
+		{name: "existing file with force", existing: "old", force: true, write: "new", want: "new"},
+		{name: "failed write keeps the existing file", existing: "old", force: true, write: "partial", writeErr: errWrite, want: "old", wantErr: true},
+		{name: "failed write creates no file", write: "partial", writeErr: errWrite, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "credit.csv")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := WriteFile(path, tt.force, func(w io.Writer) error {
+				if _, err := io.WriteString(w, tt.write); err != nil {
+					return err
+				}
+				return tt.writeErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.writeErr != nil && !errors.Is(err, tt.writeErr) {
+				t.Errorf("WriteFile() error = %v, want %v", err, tt.writeErr)
+			}
+
+			got, err := os.ReadFile(path)
+			if tt.want == "" {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("WriteFile() left %s behind", path)
+				}
+			} else if string(got) != tt.want {
+				t.Errorf("%s = %q, want %q", path, got, tt.want)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if entry.Name() != "credit.csv" {
+					t.Errorf("WriteFile() left temporary file %s behind", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFileStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	err = WriteFile(Stdout, false, func(out io.Writer) error {
+		_, err := io.WriteString(out, "export")
+		return err
+	})
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "export" {
+		t.Errorf("stdout = %q, want %q", got, "export")
+	}
+}
+
+func TestExists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credit.csv")
+	if Exists(path) {
+		t.Errorf("Exists(%s) = true before it was written", path)
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !Exists(path) {
+		t.Errorf("Exists(%s) = false after it was written", path)
+	}
+	if Exists(Stdout) {
+		t.Errorf("Exists(%q) = true", Stdout)
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(daterange.DateFormat, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	today := time.Now().Format(daterange.DateFormat)
+
+	tests := []struct {
+		name      string
+		dateRange daterange.Range
+		want      string
+	}{
+		{name: "closed range", dateRange: daterange.Range{From: day("2025-01-01"), To: day("2025-03-31")}, want: "credit-ffalor-2025-01-01_2025-03-31.csv"},
+		{name: "open end", dateRange: daterange.Range{From: day("2025-01-01")}, want: fmt.Sprintf("credit-ffalor-2025-01-01_%s.csv", today)},
+		{name: "open start", dateRange: daterange.Range{To: day("2025-03-31")}, want: "credit-ffalor-start_2025-03-31.csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultName("ffalor", tt.dateRange, "csv"); got != tt.want {
+				t.Errorf("DefaultName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
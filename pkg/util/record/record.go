@@ -0,0 +1,300 @@
+package record
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/config"
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// Record is a merged PR, issue, review or other contribution flattened into the fields used for Jira exports
+type Record struct {
+	Id          string
+	Kind        string
+	Title       string
+	Description string
+	Assignee    string
+	Reporter    string
+	RepoName    string
+	Epic        string
+	Url         string
+	Labels      []string
+	CreatedAt   string
+	ResolvedAt  string
+	// Metrics is the size of a merged PR, or of the PRs folded into an issue, nil for other items
+	// and when the metrics were not fetched
+	Metrics *types.PrMetrics
+	// Size and StoryPoints are set by Estimate for records with Metrics
+	Size        string
+	StoryPoints string
+}
+
+// Fields lists the names accepted by Record.Field
+var Fields = config.ColumnFields
+
+const (
+	// dateFormat is the format dates are exported in
+	dateFormat = "2006-01-02 15:04"
+	// JiraDateFormat is dateFormat in the notation used by the Jira importer
+	JiraDateFormat = "yyyy-MM-dd HH:mm"
+)
+
+// FromMergedPr flattens a merged PR credited to user, describing the issues folded into it after
+// the PR and taking over their labels
+func FromMergedPr(user string, pr types.MergedPr) Record {
+	description := fmt.Sprintf("%s\nURL: %s", pr.Body, pr.Url)
+	var labels []string
+
+	for _, issue := range pr.FoldedIssues {
+		description = fmt.Sprintf("%s\n\nCloses issue: %s\nURL: %s\n%s", description, issue.Title, issue.Url, issue.Body)
+		labels = append(labels, issue.Labels...)
+	}
+
+	var metrics *types.PrMetrics
+	if pr.Metrics.Fetched {
+		metrics = &pr.Metrics
+	}
+
+	return Record{
+		Id:          pr.Id,
+		Kind:        "pr",
+		Title:       pr.Title,
+		Description: strings.TrimSuffix(description, "\n"),
+		Labels:      labels,
+		Metrics:     metrics,
+		Assignee:    types.Credited(pr.User, user),
+		Reporter:    types.Credited(pr.User, user),
+		RepoName:    pr.RepoName,
+		Epic:        pr.Epic,
+		Url:         pr.Url,
+		CreatedAt:   formatDate(pr.CreatedAt),
+		ResolvedAt:  formatDate(pr.MergedAt),
+	}
+}
+
+// FromIssue flattens an issue credited to user
+func FromIssue(user string, issue types.Issue) Record {
+	description := fmt.Sprintf("%s\nURL: %s", issue.Body, issue.Url)
+	if len(issue.Labels) > 0 {
+		description = fmt.Sprintf("%s\nLabels: %s", description, strings.Join(issue.Labels, ", "))
+	}
+	if issue.Milestone != "" {
+		description = fmt.Sprintf("%s\nMilestone: %s", description, issue.Milestone)
+	}
+	if len(issue.Assignees) > 0 {
+		description = fmt.Sprintf("%s\nAssignees: %s", description, strings.Join(issue.Assignees, ", "))
+	}
+	if len(issue.ClosedBy) > 0 {
+		var urls []string
+		for _, pr := range issue.ClosedBy {
+			urls = append(urls, pr.Url)
+		}
+		description = fmt.Sprintf("%s\nClosed by: %s", description, strings.Join(urls, ", "))
+	}
+	var metrics *types.PrMetrics
+	for _, pr := range issue.FoldedPrs {
+		description = fmt.Sprintf("%s\n\nClosed by PR: %s\nURL: %s\n%s", description, pr.Title, pr.Url, pr.Body)
+		metrics = addMetrics(metrics, pr.Metrics)
+	}
+	description = strings.TrimSuffix(description, "\n")
+
+	return Record{
+		Id:          issue.Id,
+		Kind:        "issue",
+		Title:       issue.Title,
+		Description: description,
+		Assignee:    types.Credited(issue.User, user),
+		Reporter:    types.Credited(issue.User, user),
+		RepoName:    issue.RepoName,
+		Epic:        issue.Epic,
+		Url:         issue.Url,
+		Labels:      issue.Labels,
+		ResolvedAt:  formatDate(issue.ClosedAt),
+		Metrics:     metrics,
+	}
+}
+
+// FromReview flattens a review credited to user
+func FromReview(user string, review types.Review) Record {
+	description := fmt.Sprintf("%s\nPR: %s\nState: %s\nURL: %s", review.Body, review.PrUrl, review.State, review.Url)
+
+	return Record{
+		Id:          review.Id,
+		Kind:        "review",
+		Title:       review.Title,
+		Description: strings.TrimPrefix(description, "\n"),
+		Assignee:    types.Credited(review.User, user),
+		Reporter:    types.Credited(review.User, user),
+		RepoName:    review.RepoName,
+		Epic:        review.Epic,
+		Url:         review.Url,
+		CreatedAt:   formatDate(review.SubmittedAt),
+		ResolvedAt:  formatDate(review.SubmittedAt),
+	}
+}
+
+// FromContribution flattens a contribution credited to user
+func FromContribution(user string, contribution types.Contribution) Record {
+	description := fmt.Sprintf("%s\nURL: %s", contribution.Body, contribution.Url)
+
+	return Record{
+		Id:          contribution.Id,
+		Kind:        contribution.Kind,
+		Title:       contribution.Title,
+		Description: strings.TrimPrefix(description, "\n"),
+		Assignee:    types.Credited(contribution.User, user),
+		Reporter:    types.Credited(contribution.User, user),
+		RepoName:    contribution.RepoName,
+		Epic:        contribution.Epic,
+		Url:         contribution.Url,
+		CreatedAt:   formatDate(contribution.OccurredAt),
+		ResolvedAt:  formatDate(contribution.OccurredAt),
+	}
+}
+
+// Build flattens all credit of user, ordered by repository and title
+func Build(user string, credit types.Credit) []Record {
+	records := make([]Record, 0, credit.Len())
+
+	for _, pr := range credit.MergedPrs {
+		records = append(records, FromMergedPr(user, pr))
+	}
+
+	for _, issue := range credit.Issues {
+		records = append(records, FromIssue(user, issue))
+	}
+
+	for _, review := range credit.Reviews {
+		records = append(records, FromReview(user, review))
+	}
+
+	for _, contribution := range credit.Contributions {
+		records = append(records, FromContribution(user, contribution))
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].RepoName != records[j].RepoName {
+			return records[i].RepoName < records[j].RepoName
+		}
+		return records[i].Title < records[j].Title
+	})
+
+	return records
+}
+
+// Estimate sets the size and story points of the records with metrics from the configured buckets
+func Estimate(records []Record, storyPoints config.StoryPoints) {
+	for i, r := range records {
+		if r.Metrics == nil {
+			continue
+		}
+
+		bucket, ok := storyPoints.Estimate(*r.Metrics)
+		if !ok {
+			continue
+		}
+
+		records[i].Size = bucket.Size
+		records[i].StoryPoints = strconv.FormatFloat(bucket.Points, 'f', -1, 64)
+	}
+}
+
+// addMetrics adds m to total, starting from zero when total is nil. Metrics that were not
+// fetched are left out.
+func addMetrics(total *types.PrMetrics, m types.PrMetrics) *types.PrMetrics {
+	if !m.Fetched {
+		return total
+	}
+	if total == nil {
+		total = &types.PrMetrics{Fetched: true}
+	}
+
+	total.Additions += m.Additions
+	total.Deletions += m.Deletions
+	total.ChangedFiles += m.ChangedFiles
+	total.Commits += m.Commits
+	total.Reviews += m.Reviews
+	total.Comments += m.Comments
+
+	return total
+}
+
+// Field returns the value(s) of the named field, issueTypes maps Kind to the Jira issue type
+func (r Record) Field(name string, issueTypes map[string]string) ([]string, error) {
+	switch name {
+	case "id":
+		return []string{r.Id}, nil
+	case "kind":
+		return []string{r.Kind}, nil
+	case "type":
+		if issueType, ok := issueTypes[r.Kind]; ok {
+			return []string{issueType}, nil
+		}
+		return []string{r.Kind}, nil
+	case "title":
+		return []string{r.Title}, nil
+	case "description":
+		return []string{r.Description}, nil
+	case "assignee":
+		return []string{r.Assignee}, nil
+	case "reporter":
+		return []string{r.Reporter}, nil
+	case "repo":
+		return []string{r.RepoName}, nil
+	case "epic":
+		return []string{r.Epic}, nil
+	case "url":
+		return []string{r.Url}, nil
+	case "labels":
+		// jira labels can not contain spaces
+		labels := make([]string, len(r.Labels))
+		for i, label := range r.Labels {
+			labels[i] = strings.ReplaceAll(label, " ", "-")
+		}
+		return labels, nil
+	case "created":
+		return []string{r.CreatedAt}, nil
+	case "resolved":
+		return []string{r.ResolvedAt}, nil
+	case "additions", "deletions", "files", "commits", "reviews", "comments":
+		return []string{r.metric(name)}, nil
+	case "size":
+		return []string{r.Size}, nil
+	case "points":
+		return []string{r.StoryPoints}, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q, valid fields are: %s", name, strings.Join(Fields, ", "))
+}
+
+// metric returns the named PR metric, empty for records without metrics
+func (r Record) metric(name string) string {
+	if r.Metrics == nil {
+		return ""
+	}
+
+	values := map[string]int{
+		"additions": r.Metrics.Additions,
+		"deletions": r.Metrics.Deletions,
+		"files":     r.Metrics.ChangedFiles,
+		"commits":   r.Metrics.Commits,
+		"reviews":   r.Metrics.Reviews,
+		"comments":  r.Metrics.Comments,
+	}
+
+	return strconv.Itoa(values[name])
+}
+
+// formatDate converts a GitHub timestamp into dateFormat, returning it unchanged if it can't be parsed
+func formatDate(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+
+	return t.Format(dateFormat)
+}
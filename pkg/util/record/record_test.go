@@ -0,0 +1,118 @@
+package record
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+func TestField(t *testing.T) {
+	pr := Record{
+		Id:          "PR_1",
+		Kind:        "pr",
+		Title:       "Fix the thing",
+		Description: "Fixes it",
+		Assignee:    "octocat",
+		Reporter:    "hubot",
+		RepoName:    "credit",
+		Epic:        "CR-1",
+		Url:         "https://github.com/ffalor/credit/pull/1",
+		Labels:      []string{"bug", "good first issue"},
+		CreatedAt:   "2024-01-01 10:00",
+		ResolvedAt:  "2024-01-02 11:00",
+		Metrics:     &types.PrMetrics{Additions: 10, Deletions: 4, ChangedFiles: 2, Commits: 3, Reviews: 1, Comments: 5},
+		Size:        "S",
+		StoryPoints: "2",
+	}
+	issue := Record{Kind: "issue"}
+	issueTypes := map[string]string{"pr": "Story"}
+
+	tests := []struct {
+		name    string
+		record  Record
+		field   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "id", record: pr, field: "id", want: []string{"PR_1"}},
+		{name: "kind", record: pr, field: "kind", want: []string{"pr"}},
+		{name: "mapped type", record: pr, field: "type", want: []string{"Story"}},
+		{name: "unmapped type falls back to kind", record: issue, field: "type", want: []string{"issue"}},
+		{name: "title", record: pr, field: "title", want: []string{"Fix the thing"}},
+		{name: "description", record: pr, field: "description", want: []string{"Fixes it"}},
+		{name: "assignee", record: pr, field: "assignee", want: []string{"octocat"}},
+		{name: "reporter", record: pr, field: "reporter", want: []string{"hubot"}},
+		{name: "repo", record: pr, field: "repo", want: []string{"credit"}},
+		{name: "epic", record: pr, field: "epic", want: []string{"CR-1"}},
+		{name: "url", record: pr, field: "url", want: []string{"https://github.com/ffalor/credit/pull/1"}},
+		{name: "labels replace spaces", record: pr, field: "labels", want: []string{"bug", "good-first-issue"}},
+		{name: "no labels", record: issue, field: "labels", want: []string{}},
+		{name: "created", record: pr, field: "created", want: []string{"2024-01-01 10:00"}},
+		{name: "resolved", record: pr, field: "resolved", want: []string{"2024-01-02 11:00"}},
+		{name: "additions", record: pr, field: "additions", want: []string{"10"}},
+		{name: "deletions", record: pr, field: "deletions", want: []string{"4"}},
+		{name: "files", record: pr, field: "files", want: []string{"2"}},
+		{name: "commits", record: pr, field: "commits", want: []string{"3"}},
+		{name: "reviews", record: pr, field: "reviews", want: []string{"1"}},
+		{name: "comments", record: pr, field: "comments", want: []string{"5"}},
+		{name: "metric without metrics", record: issue, field: "additions", want: []string{""}},
+		{name: "size", record: pr, field: "size", want: []string{"S"}},
+		{name: "points", record: pr, field: "points", want: []string{"2"}},
+		{name: "unknown", record: pr, field: "priority", wantErr: true},
+		{name: "case sensitive", record: pr, field: "Title", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.record.Field(tt.field, issueTypes)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Field() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Field() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Field() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsAreKnown(t *testing.T) {
+	for _, name := range Fields {
+		if _, err := (Record{}).Field(name, nil); err != nil {
+			t.Errorf("Field(%q) error = %v", name, err)
+		}
+	}
+}
+
+func TestRecordMetrics(t *testing.T) {
+	fetched := types.PrMetrics{Additions: 10, Deletions: 2, Commits: 1, Fetched: true}
+
+	tests := []struct {
+		name   string
+		record Record
+		want   *types.PrMetrics
+	}{
+		{name: "fetched pr", record: FromMergedPr("octocat", types.MergedPr{Metrics: fetched}), want: &fetched},
+		{name: "pr without metrics", record: FromMergedPr("octocat", types.MergedPr{})},
+		{
+			name:   "issue sums its folded prs",
+			record: FromIssue("octocat", types.Issue{FoldedPrs: []types.MergedPr{{Metrics: fetched}, {}, {Metrics: fetched}}}),
+			want:   &types.PrMetrics{Additions: 20, Deletions: 4, Commits: 2, Fetched: true},
+		},
+		{name: "issue without folded prs", record: FromIssue("octocat", types.Issue{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.record.Metrics, tt.want) {
+				t.Errorf("Metrics = %+v, want %+v", tt.record.Metrics, tt.want)
+			}
+		})
+	}
+}
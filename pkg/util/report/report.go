@@ -0,0 +1,336 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/daterange"
+	"github.com/ffalor/credit/pkg/util/text"
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Formats lists the report formats that have a built in template
+var Formats = []string{"markdown"}
+
+// Data is the data model templates are executed against.
+//
+// Merged PRs, issues, reviews and contributions have the fields of types.MergedPr, types.Issue,
+// types.Review and types.Contribution, timestamps are
+// RFC 3339 strings that can be formatted with the date helper. Besides the text/template
+// builtins templates can use:
+//
+//	date "Jan 2, 2006" .MergedAt   format a timestamp with a Go time layout
+//	truncate 80 .Title             shorten text to at most n characters, ending in …
+//	md .Title                      escape markdown special characters
+//	join .Labels ", "              join a list of strings
+//	groupByRepo .MergedPrs .Issues group merged PRs and issues, and optionally .Reviews, by repository
+type Data struct {
+	User string
+	// Range is the date range credit was fetched for, .Range.From and .Range.To are time.Time
+	// values where a zero To means the range is open ended
+	Range daterange.Range
+	// ShowSummary is set when a summary line was requested for every section
+	ShowSummary bool
+	// MergedPrs are all merged PRs ordered by merge date
+	MergedPrs []types.MergedPr
+	// Issues are all closed issues ordered by close date
+	Issues []types.Issue
+	// Reviews are all reviews ordered by submit date
+	Reviews []types.Review
+	// Contributions are all commits and created repositories ordered by date
+	Contributions []types.Contribution
+	// Repositories groups all credit by repository and month
+	Repositories []Repository
+	Stats        Stats
+}
+
+// Stats counts the credit in Data
+type Stats struct {
+	MergedPrs     int
+	Issues        int
+	Reviews       int
+	Contributions int
+	Repositories  int
+}
+
+// RepoGroup holds the merged PRs and issues of a single repository, see groupByRepo
+type RepoGroup struct {
+	Name      string
+	MergedPrs []types.MergedPr
+	Issues    []types.Issue
+	Reviews   []types.Review
+}
+
+// Repository groups the credit of a single repository by month
+type Repository struct {
+	Name   string
+	Months []Month
+}
+
+// Month holds the credit of a repository within a single month, newest first
+type Month struct {
+	// Name is the month and year e.g. January 2025, or Undated when no date is known
+	Name      string
+	MergedPrs []types.MergedPr
+	Issues    []types.Issue
+	Reviews   []types.Review
+	// Contributions are commits and created repositories
+	Contributions []types.Contribution
+	// Summary is a one line summary of the month e.g. 3 merged PRs, 1 closed issue
+	Summary string
+}
+
+// NewData groups merged PRs by merge month, issues by close month, reviews by submit month and
+// contributions by the month they occurred within each repository
+func NewData(user string, dateRange daterange.Range, credit types.Credit) Data {
+	type monthKey struct {
+		repo  string
+		month string
+	}
+
+	months := make(map[monthKey]*Month)
+	month := func(repo string, timestamp string) *Month {
+		name, key := "Undated", ""
+		if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+			name, key = t.Format("January 2006"), t.Format("2006-01")
+		}
+
+		k := monthKey{repo: repo, month: key}
+		if _, ok := months[k]; !ok {
+			months[k] = &Month{Name: name}
+		}
+		return months[k]
+	}
+
+	for _, pr := range credit.MergedPrs {
+		m := month(pr.RepoName, pr.MergedAt)
+		m.MergedPrs = append(m.MergedPrs, pr)
+	}
+
+	for _, issue := range credit.Issues {
+		m := month(issue.RepoName, issue.ClosedAt)
+		m.Issues = append(m.Issues, issue)
+	}
+
+	for _, review := range credit.Reviews {
+		m := month(review.RepoName, review.SubmittedAt)
+		m.Reviews = append(m.Reviews, review)
+	}
+
+	for _, contribution := range credit.Contributions {
+		m := month(contribution.RepoName, contribution.OccurredAt)
+		m.Contributions = append(m.Contributions, contribution)
+	}
+
+	keys := make([]monthKey, 0, len(months))
+	for k := range months {
+		keys = append(keys, k)
+	}
+
+	// repositories alphabetically, months newest first with undated last
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].repo != keys[j].repo {
+			return keys[i].repo < keys[j].repo
+		}
+		return keys[i].month > keys[j].month
+	})
+
+	data := Data{
+		User:  user,
+		Range: dateRange,
+	}
+
+	for _, pr := range credit.MergedPrs {
+		data.MergedPrs = append(data.MergedPrs, pr)
+	}
+	for _, issue := range credit.Issues {
+		data.Issues = append(data.Issues, issue)
+	}
+	for _, review := range credit.Reviews {
+		data.Reviews = append(data.Reviews, review)
+	}
+	for _, contribution := range credit.Contributions {
+		data.Contributions = append(data.Contributions, contribution)
+	}
+	sortByDate(data.MergedPrs, data.Issues, data.Reviews, data.Contributions)
+
+	for _, k := range keys {
+		m := months[k]
+
+		sortByDate(m.MergedPrs, m.Issues, m.Reviews, m.Contributions)
+		m.Summary = summary(len(m.MergedPrs), len(m.Issues), len(m.Reviews), len(m.Contributions))
+
+		if len(data.Repositories) == 0 || data.Repositories[len(data.Repositories)-1].Name != k.repo {
+			data.Repositories = append(data.Repositories, Repository{Name: k.repo})
+		}
+
+		repo := &data.Repositories[len(data.Repositories)-1]
+		repo.Months = append(repo.Months, *m)
+	}
+
+	data.Stats = Stats{
+		MergedPrs:     len(data.MergedPrs),
+		Issues:        len(data.Issues),
+		Reviews:       len(data.Reviews),
+		Contributions: len(data.Contributions),
+		Repositories:  len(data.Repositories),
+	}
+
+	return data
+}
+
+// sortByDate orders merged PRs by merge date, issues by close date, reviews by submit date and
+// contributions by the date they occurred
+func sortByDate(mergedPrs []types.MergedPr, issues []types.Issue, reviews []types.Review, contributions []types.Contribution) {
+	sort.SliceStable(mergedPrs, func(i, j int) bool { return mergedPrs[i].MergedAt < mergedPrs[j].MergedAt })
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].ClosedAt < issues[j].ClosedAt })
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].SubmittedAt < reviews[j].SubmittedAt })
+	sort.SliceStable(contributions, func(i, j int) bool { return contributions[i].OccurredAt < contributions[j].OccurredAt })
+}
+
+// Template returns the built in template for format, or the template at path if path is set
+func Template(format string, path string) (*template.Template, error) {
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return template.New(filepath.Base(path)).Funcs(Funcs()).Parse(string(content))
+	}
+
+	content, err := templates.ReadFile(fmt.Sprintf("templates/%s.tmpl", format))
+	if err != nil {
+		return nil, fmt.Errorf("invalid format %q, please use one of: %s", format, strings.Join(Formats, ", "))
+	}
+
+	return template.New(format).Funcs(Funcs()).Parse(string(content))
+}
+
+// Funcs returns the helper functions available to templates
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"date":        formatDate,
+		"join":        strings.Join,
+		"truncate":    truncate,
+		"md":          escapeMarkdown,
+		"groupByRepo": groupByRepo,
+	}
+}
+
+// Render executes tmpl against data into w
+func Render(w io.Writer, tmpl *template.Template, data Data) error {
+	return tmpl.Execute(w, data)
+}
+
+// formatDate formats a github timestamp with layout, returning it unchanged if it can't be parsed
+func formatDate(layout string, timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+
+	return t.Format(layout)
+}
+
+// truncate shortens text to at most length characters, ending in … when shortened
+func truncate(length int, text string) string {
+	runes := []rune(text)
+	if length <= 0 || len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+// markdownEscaper escapes the characters that change the meaning of inline markdown
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`,
+	"(", `\(`, ")", `\)`, "#", `\#`, "+", `\+`, "-", `\-`, "!", `\!`, "|", `\|`, "<", `\<`, ">", `\>`,
+)
+
+// escapeMarkdown escapes markdown special characters so text renders literally
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// groupByRepo groups merged PRs, issues and optionally reviews by repository, ordered by repository name
+func groupByRepo(mergedPrs []types.MergedPr, issues []types.Issue, reviews ...[]types.Review) []RepoGroup {
+	groups := make(map[string]*RepoGroup)
+	group := func(name string) *RepoGroup {
+		if _, ok := groups[name]; !ok {
+			groups[name] = &RepoGroup{Name: name}
+		}
+		return groups[name]
+	}
+
+	for _, pr := range mergedPrs {
+		g := group(pr.RepoName)
+		g.MergedPrs = append(g.MergedPrs, pr)
+	}
+	for _, issue := range issues {
+		g := group(issue.RepoName)
+		g.Issues = append(g.Issues, issue)
+	}
+	for _, list := range reviews {
+		for _, review := range list {
+			g := group(review.RepoName)
+			g.Reviews = append(g.Reviews, review)
+		}
+	}
+
+	result := make([]RepoGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+// summary returns a one line summary of the number of merged PRs, closed issues, reviews and
+// other contributions
+func summary(mergedPrs int, issues int, reviews int, contributions int) string {
+	var parts []string
+	if mergedPrs > 0 {
+		parts = append(parts, text.Plural(mergedPrs, "merged PR", "merged PRs"))
+	}
+	if issues > 0 {
+		parts = append(parts, text.Plural(issues, "closed issue", "closed issues"))
+	}
+	if reviews > 0 {
+		parts = append(parts, text.Plural(reviews, "review", "reviews"))
+	}
+	if contributions > 0 {
+		parts = append(parts, text.Plural(contributions, "other contribution", "other contributions"))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Writer renders credit with a template, matching the other export writers
+type Writer struct {
+	tmpl      *template.Template
+	dateRange daterange.Range
+}
+
+func NewWriter(tmpl *template.Template, dateRange daterange.Range) *Writer {
+	return &Writer{
+		tmpl:      tmpl,
+		dateRange: dateRange,
+	}
+}
+
+// Write renders all credit of user into out
+func (w *Writer) Write(out io.Writer, user string, credit types.Credit) error {
+	return Render(out, w.tmpl, NewData(user, w.dateRange, credit))
+}
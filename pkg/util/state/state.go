@@ -0,0 +1,189 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+// Entry records when and where a credit item was exported
+type Entry struct {
+	ExportedAt time.Time `json:"exportedAt"`
+	// Target is where the item was exported to, csv for the Jira csv import or jira
+	Target string `json:"target"`
+	// JiraKey is the key of the Jira issue created for the item, if any
+	JiraKey string `json:"jiraKey,omitempty"`
+}
+
+// Store remembers which credit items have already been exported, keyed by github node id
+type Store struct {
+	path     string
+	Exported map[string]Entry `json:"exported"`
+}
+
+// DefaultPath returns the default state file location, $XDG_DATA_HOME/credit/state.json
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "credit", "state.json"), nil
+}
+
+// Load reads the state file at path, or the default location if path is empty.
+// A missing state file results in an empty store.
+func Load(path string) (*Store, error) {
+	if path == "" {
+		defaultPath, err := DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+
+	store := &Store{
+		path:     path,
+		Exported: make(map[string]Entry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("could not parse state file %s: %w", path, err)
+	}
+
+	if store.Exported == nil {
+		store.Exported = make(map[string]Entry)
+	}
+
+	return store, nil
+}
+
+// Path returns the location of the state file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Has reports whether the item with the given id has already been exported
+func (s *Store) Has(id string) bool {
+	_, ok := s.Exported[id]
+	return ok
+}
+
+// Mark records the item with the given id as exported
+func (s *Store) Mark(id string, entry Entry) {
+	s.Exported[id] = entry
+}
+
+// Reset forgets every exported item
+func (s *Store) Reset() {
+	s.Exported = make(map[string]Entry)
+}
+
+// Unexported returns the credit that has not been exported yet along with the number of items
+// that were left out
+func (s *Store) Unexported(credit types.Credit) (types.Credit, int) {
+	unexported := types.NewCredit()
+	skipped := 0
+
+	for id, pr := range credit.MergedPrs {
+		if s.Has(id) {
+			skipped++
+			continue
+		}
+		unexported.MergedPrs[id] = pr
+	}
+
+	for id, issue := range credit.Issues {
+		if s.Has(id) {
+			skipped++
+			continue
+		}
+		unexported.Issues[id] = issue
+	}
+
+	for id, review := range credit.Reviews {
+		if s.Has(id) {
+			skipped++
+			continue
+		}
+		unexported.Reviews[id] = review
+	}
+
+	for id, contribution := range credit.Contributions {
+		if s.Has(id) {
+			skipped++
+			continue
+		}
+		unexported.Contributions[id] = contribution
+	}
+
+	return unexported, skipped
+}
+
+// MarkCredit records every item of credit as exported, including the items folded into others
+func (s *Store) MarkCredit(credit types.Credit, entry Entry) {
+	for id, pr := range credit.MergedPrs {
+		s.Mark(id, entry)
+		for _, issue := range pr.FoldedIssues {
+			s.Mark(issue.Id, entry)
+		}
+	}
+	for id, issue := range credit.Issues {
+		s.Mark(id, entry)
+		for _, pr := range issue.FoldedPrs {
+			s.Mark(pr.Id, entry)
+		}
+	}
+	for id := range credit.Reviews {
+		s.Mark(id, entry)
+	}
+	for id := range credit.Contributions {
+		s.Mark(id, entry)
+	}
+}
+
+// Save writes the store to its state file, replacing it atomically
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
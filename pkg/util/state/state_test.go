@@ -0,0 +1,201 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantIds []string
+		wantErr bool
+	}{
+		{name: "missing file is empty", path: filepath.Join(dir, "missing", "state.json")},
+		{name: "empty object", path: write("empty.json", `{}`)},
+		{name: "null exported", path: write("null.json", `{"exported": null}`)},
+		{
+			name:    "exported items",
+			path:    write("state.json", `{"exported": {"PR_1": {"exportedAt": "2024-01-01T00:00:00Z", "target": "csv"}, "I_1": {"target": "jira", "jiraKey": "CR-1"}}}`),
+			wantIds: []string{"I_1", "PR_1"},
+		},
+		{name: "corrupt file", path: write("corrupt.json", `{"exported": `), wantErr: true},
+		{name: "directory", path: dir, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := Load(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Load() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			var ids []string
+			for id := range store.Exported {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			if !reflect.DeepEqual(ids, tt.wantIds) {
+				t.Errorf("exported = %v, want %v", ids, tt.wantIds)
+			}
+			if store.Path() != tt.path {
+				t.Errorf("Path() = %s, want %s", store.Path(), tt.path)
+			}
+		})
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credit", "state.json")
+
+	store, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exportedAt := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	store.Mark("PR_1", Entry{ExportedAt: exportedAt, Target: "csv"})
+	store.Mark("I_1", Entry{ExportedAt: exportedAt, Target: "jira", JiraKey: "CR-1"})
+
+	// the directory of the state file is created
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Exported, store.Exported) {
+		t.Errorf("loaded %+v, want %+v", loaded.Exported, store.Exported)
+	}
+
+	// no temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("state directory holds %d files, want only the state file", len(entries))
+	}
+
+	loaded.Reset()
+	if err := loaded.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reset, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(reset.Exported) != 0 {
+		t.Errorf("exported after reset = %v, want none", reset.Exported)
+	}
+}
+
+func newCredit() types.Credit {
+	credit := types.NewCredit()
+	credit.MergedPrs["PR_1"] = types.MergedPr{Id: "PR_1", FoldedIssues: []types.Issue{{Id: "I_2"}}}
+	credit.MergedPrs["PR_2"] = types.MergedPr{Id: "PR_2"}
+	credit.Issues["I_1"] = types.Issue{Id: "I_1", FoldedPrs: []types.MergedPr{{Id: "PR_3"}}}
+	credit.Reviews["R_1"] = types.Review{Id: "R_1"}
+	credit.Contributions["commits:octocat:o/r:2024-01-01"] = types.Contribution{Id: "commits:octocat:o/r:2024-01-01"}
+	return credit
+}
+
+func TestMarkCredit(t *testing.T) {
+	store := &Store{Exported: make(map[string]Entry)}
+	entry := Entry{Target: "csv"}
+
+	store.MarkCredit(newCredit(), entry)
+
+	// folded items are marked along with the item they were folded into
+	for _, id := range []string{"PR_1", "PR_2", "I_1", "I_2", "PR_3", "R_1", "commits:octocat:o/r:2024-01-01"} {
+		if got, ok := store.Exported[id]; !ok || got != entry {
+			t.Errorf("Exported[%s] = %+v, %v, want %+v", id, got, ok, entry)
+		}
+	}
+	if len(store.Exported) != 7 {
+		t.Errorf("marked %d items, want 7", len(store.Exported))
+	}
+}
+
+func TestUnexported(t *testing.T) {
+	tests := []struct {
+		name        string
+		exported    []string
+		wantIds     []string
+		wantSkipped int
+	}{
+		{
+			name:    "nothing exported",
+			wantIds: []string{"I_1", "PR_1", "PR_2", "R_1", "commits:octocat:o/r:2024-01-01"},
+		},
+		{
+			name:        "every kind",
+			exported:    []string{"PR_1", "I_1", "R_1", "commits:octocat:o/r:2024-01-01"},
+			wantIds:     []string{"PR_2"},
+			wantSkipped: 4,
+		},
+		{
+			name:     "unrelated ids",
+			exported: []string{"PR_9", "commits:hubot:o/r:2024-01-01"},
+			wantIds:  []string{"I_1", "PR_1", "PR_2", "R_1", "commits:octocat:o/r:2024-01-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &Store{Exported: make(map[string]Entry)}
+			for _, id := range tt.exported {
+				store.Mark(id, Entry{Target: "csv"})
+			}
+
+			credit := newCredit()
+			unexported, skipped := store.Unexported(credit)
+
+			var ids []string
+			for id := range unexported.MergedPrs {
+				ids = append(ids, id)
+			}
+			for id := range unexported.Issues {
+				ids = append(ids, id)
+			}
+			for id := range unexported.Reviews {
+				ids = append(ids, id)
+			}
+			for id := range unexported.Contributions {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			if !reflect.DeepEqual(ids, tt.wantIds) {
+				t.Errorf("unexported = %v, want %v", ids, tt.wantIds)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+			if credit.Len() != 5 {
+				t.Errorf("credit was modified, has %d items", credit.Len())
+			}
+		})
+	}
+}
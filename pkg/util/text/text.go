@@ -0,0 +1,12 @@
+package text
+
+import "fmt"
+
+// Plural returns n followed by singular when n is 1 and by plural otherwise, e.g. "1 commit" or
+// "2 commits"
+func Plural(n int, singular string, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
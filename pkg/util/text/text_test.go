@@ -0,0 +1,20 @@
+package text
+
+import "testing"
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "0 commits"},
+		{n: 1, want: "1 commit"},
+		{n: 2, want: "2 commits"},
+	}
+
+	for _, tt := range tests {
+		if got := Plural(tt.n, "commit", "commits"); got != tt.want {
+			t.Errorf("Plural(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
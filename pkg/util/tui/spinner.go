@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type statusMsg string
+
+type doneMsg struct{}
+
+// spinnerModel shows a spinner and the latest status while work runs in the background
+type spinnerModel struct {
+	spinner  spinner.Model
+	status   string
+	cancel   context.CancelFunc
+	quitting bool
+}
+
+func (m spinnerModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case statusMsg:
+		m.status = string(msg)
+		return m, nil
+	case doneMsg:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if key.Matches(msg, keys.Quit) {
+			m.status = "Cancelling..."
+			m.cancel()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+func (m spinnerModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s\n", m.spinner.View(), m.status)
+}
+
+// RunWithSpinner runs fn while showing a spinner on stderr with the latest status passed to
+// the status callback. ctrl+c cancels the context given to fn.
+func RunWithSpinner(ctx context.Context, title string, fn func(ctx context.Context, status func(string)) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = focusedItemStyle
+
+	p := tea.NewProgram(spinnerModel{
+		spinner: s,
+		status:  title,
+		cancel:  cancel,
+	}, tea.WithOutput(os.Stderr))
+
+	errs := make(chan error, 1)
+	go func() {
+		err := fn(ctx, func(status string) {
+			p.Send(statusMsg(status))
+		})
+		errs <- err
+		p.Send(doneMsg{})
+	}()
+
+	if _, err := p.Run(); err != nil {
+		cancel()
+		<-errs
+		return err
+	}
+
+	return <-errs
+}
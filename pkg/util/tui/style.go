@@ -20,4 +20,5 @@ var (
 				BorderForeground(lipgloss.Color(""))
 	titleStyle     = lipgloss.NewStyle().MarginLeft(2).Background(lipgloss.Color("69"))
 	statusBarStyle = list.DefaultStyles().StatusBar.MarginLeft(2)
+	kindStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 )
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/76creates/stickers"
 	"github.com/charmbracelet/bubbles/help"
@@ -12,6 +13,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/ffalor/credit/pkg/util/text"
 	"github.com/ffalor/credit/pkg/util/types"
 	"github.com/muesli/reflow/truncate"
 )
@@ -27,6 +29,8 @@ type keyMap struct {
 	Help   key.Binding
 	Tab    key.Binding
 	Delete key.Binding
+	Epic   key.Binding
+	Split  key.Binding
 	Quit   key.Binding
 }
 
@@ -36,8 +40,8 @@ func (k keyMap) ShortHelp() []key.Binding {
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Enter, k.Tab, k.Delete, k.Up},          // first column
-		{k.Down, k.Left, k.Right, k.Help, k.Quit}, // second column
+		{k.Enter, k.Tab, k.Delete, k.Epic, k.Split, k.Submit}, // first column
+		{k.Up, k.Down, k.Left, k.Right, k.Help, k.Quit},       // second column
 	}
 }
 
@@ -58,6 +62,14 @@ var keys = keyMap{
 		key.WithKeys("ctrl+d"),
 		key.WithHelp("ctrl+d", "delete"),
 	),
+	Epic: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "apply epic to selected"),
+	),
+	Split: key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "split folded PR and issues"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter", " "),
 		key.WithHelp("enter", "select"),
@@ -94,6 +106,7 @@ type sessionState uint
 const (
 	issueListView sessionState = iota
 	issueSummaryInputView
+	issueEpicInputView
 	issueDescriptionInputView
 	// issue list width and height
 	defaultHeight = 14
@@ -118,12 +131,37 @@ const (
 	issueDescriptionRow
 )
 
+// item kinds, the list is sectioned in this order
+const (
+	kindMergedPr = "pr"
+	kindIssue    = "issue"
+	kindReview   = "review"
+)
+
+// kindLabels are the badges shown in front of each list item
+var kindLabels = map[string]string{
+	kindMergedPr:                 "PR",
+	kindIssue:                    "Issue",
+	kindReview:                   "Review",
+	types.ContributionCommits:    "Commits",
+	types.ContributionRepository: "Repo",
+}
+
 type issueItem struct {
 	id          string
+	kind        string
 	summary     string
 	description string
+	epic        string
 	repoName    string
-	selected    bool
+	user        string
+	// attribution is how an issue is credited to user
+	attribution string
+	// folded is the number of issues or PRs folded into the item
+	folded int
+	// metrics summarizes the size of a PR
+	metrics  string
+	selected bool
 }
 
 func (i issueItem) FilterValue() string {
@@ -154,10 +192,15 @@ func (d issueItemDelegate) Render(w io.Writer, m list.Model, index int, listItem
 		chosen = "✓"
 	}
 
-	textwidth := uint(m.Width() - normalItem.GetPaddingLeft() - normalItem.GetPaddingRight())
+	label := kindLabels[i.kind]
+	if i.folded > 0 {
+		label = fmt.Sprintf("%s+%d", label, i.folded)
+	}
+	badge := fmt.Sprintf("%-7s", label)
+	textwidth := uint(m.Width() - normalItem.GetPaddingLeft() - normalItem.GetPaddingRight() - len(badge) - 1)
 	issueSummary = truncate.StringWithTail(i.summary, textwidth, "…")
 
-	str := fmt.Sprintf("%s %s", selectedItemStyle.Render(chosen), issueSummary)
+	str := fmt.Sprintf("%s %s %s", selectedItemStyle.Render(chosen), kindStyle.Render(badge), issueSummary)
 
 	fn := normalItem.Render
 	if index == m.Index() {
@@ -176,40 +219,73 @@ type model struct {
 	help               help.Model
 	lastKey            string
 	quitting           bool
+	submitted          bool
 	selected           map[string]struct{}
-	issues             map[string]types.Issue
-	mergedPrs          map[string]types.MergedPr
-	epic               string
+	credit             types.Credit
 	issueRepoName      string
+	issueKind          string
+	issueUser          string
+	issueAttribution   string
+	issueFolded        int
+	issueMetrics       string
 	issueSummaryTi     textinput.Model
+	issueEpicTi        textinput.Model
 	issueDescriptionTa textarea.Model
 	issueList          list.Model // left side list of issues
 	focusedView        sessionState
 	mainFlexBox        *stickers.FlexBox // main flexbox includes issue list and issue editor
 }
 
-func InitialModel(mergedPrs map[string]types.MergedPr, issues map[string]types.Issue) (model, error) {
+func InitialModel(credit types.Credit) (model, error) {
 
 	choices := []list.Item{}
 
-	for _, pr := range mergedPrs {
+	for _, pr := range credit.MergedPrs {
+		choices = append(choices, newMergedPrItem(pr))
+	}
+
+	for _, issue := range credit.Issues {
+		choices = append(choices, newIssueItem(issue))
+	}
+
+	for _, review := range credit.Reviews {
 		choices = append(choices, issueItem{
-			id:          pr.Id,
-			summary:     pr.Title,
-			description: pr.Body,
-			repoName:    pr.RepoName,
+			id:          review.Id,
+			kind:        kindReview,
+			summary:     review.Title,
+			description: review.Body,
+			epic:        review.Epic,
+			repoName:    review.RepoName,
+			user:        review.User,
 		})
 	}
 
-	for _, issue := range issues {
+	for _, contribution := range credit.Contributions {
 		choices = append(choices, issueItem{
-			id:          issue.Id,
-			summary:     issue.Title,
-			description: issue.Body,
-			repoName:    issue.RepoName,
+			id:          contribution.Id,
+			kind:        contribution.Kind,
+			summary:     contribution.Title,
+			description: contribution.Body,
+			epic:        contribution.Epic,
+			repoName:    contribution.RepoName,
+			user:        contribution.User,
 		})
 	}
 
+	// section the list by kind, merged PRs first then issues, reviews and other contributions
+	kindOrder := map[string]int{kindMergedPr: 0, kindIssue: 1, kindReview: 2, types.ContributionCommits: 3, types.ContributionRepository: 4}
+	sort.SliceStable(choices, func(i, j int) bool {
+		a, b := choices[i].(issueItem), choices[j].(issueItem)
+		if a.kind != b.kind {
+			return kindOrder[a.kind] < kindOrder[b.kind]
+		}
+		return a.summary < b.summary
+	})
+
+	if len(choices) == 0 {
+		return model{}, fmt.Errorf("no issues to review")
+	}
+
 	l := list.New(choices, issueItemDelegate{}, defaultWidth, defaultWidth)
 	l.Title = "Unassigned Issues"
 	l.Styles.PaginationStyle = paginationStyle
@@ -222,6 +298,9 @@ func InitialModel(mergedPrs map[string]types.MergedPr, issues map[string]types.I
 		return []key.Binding{
 			keys.Enter,
 			keys.Delete,
+			keys.Epic,
+			keys.Split,
+			keys.Submit,
 			keys.Quit,
 			keys.Tab,
 		}
@@ -239,6 +318,11 @@ func InitialModel(mergedPrs map[string]types.MergedPr, issues map[string]types.I
 	issueSummaryInput.Prompt = "Issue Summary: "
 	issueSummaryInput.SetValue(selectedItem.summary)
 
+	issueEpicInput := textinput.New()
+	issueEpicInput.Placeholder = "Epic Link"
+	issueEpicInput.Prompt = "Epic Link: "
+	issueEpicInput.SetValue(selectedItem.epic)
+
 	issueDescriptionInput := textarea.New()
 	issueDescriptionInput.Placeholder = "Issue Description"
 	issueDescriptionInput.ShowLineNumbers = false
@@ -262,27 +346,68 @@ func InitialModel(mergedPrs map[string]types.MergedPr, issues map[string]types.I
 		issueList:          l,
 		keys:               keys,
 		help:               l.Help,
-		issues:             issues,
-		mergedPrs:          mergedPrs,
+		credit:             credit,
 		issueSummaryTi:     issueSummaryInput,
+		issueEpicTi:        issueEpicInput,
 		issueDescriptionTa: issueDescriptionInput,
 		mainFlexBox:        mainFlexBox,
 		issueRepoName:      selectedItem.repoName,
+		issueKind:          selectedItem.kind,
+		issueUser:          selectedItem.user,
+		issueAttribution:   selectedItem.attribution,
+		issueFolded:        selectedItem.folded,
+		issueMetrics:       selectedItem.metrics,
 	}, nil
 }
 
+func newMergedPrItem(pr types.MergedPr) issueItem {
+	return issueItem{
+		id:          pr.Id,
+		kind:        kindMergedPr,
+		summary:     pr.Title,
+		description: pr.Body,
+		epic:        pr.Epic,
+		repoName:    pr.RepoName,
+		user:        pr.User,
+		folded:      len(pr.FoldedIssues),
+		metrics:     metricsSummary(pr.Metrics),
+	}
+}
+
+// metricsSummary describes the size of a PR and the activity on it, empty when the metrics were
+// not fetched
+func metricsSummary(m types.PrMetrics) string {
+	if !m.Fetched {
+		return ""
+	}
+
+	return fmt.Sprintf("+%d -%d in %s, %s, %s, %s",
+		m.Additions, m.Deletions,
+		text.Plural(m.ChangedFiles, "file", "files"),
+		text.Plural(m.Commits, "commit", "commits"),
+		text.Plural(m.Reviews, "review", "reviews"),
+		text.Plural(m.Comments, "comment", "comments"))
+}
+
+func newIssueItem(issue types.Issue) issueItem {
+	return issueItem{
+		id:          issue.Id,
+		kind:        kindIssue,
+		summary:     issue.Title,
+		description: issue.Body,
+		epic:        issue.Epic,
+		repoName:    issue.RepoName,
+		user:        issue.User,
+		attribution: issue.Attribution,
+		folded:      len(issue.FoldedPrs),
+	}
+}
+
 func (m model) Init() tea.Cmd {
 	return textinput.Blink
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	// if m.epic == "" {
-	// 	prompt := &survey.Input{
-	// 		Message: "Please enter the epic number: ",
-	// 	}
-	// 	survey.AskOne(prompt, &m.epic)
-	// }
-
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -319,7 +444,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				selectedItem, ok := m.issueList.Items()[idx].(issueItem)
 				if ok {
 					m.issueRepoName = selectedItem.repoName
+					m.issueKind = selectedItem.kind
+					m.issueUser = selectedItem.user
+					m.issueAttribution = selectedItem.attribution
+					m.issueFolded = selectedItem.folded
+					m.issueMetrics = selectedItem.metrics
 					m.issueSummaryTi.SetValue(selectedItem.summary)
+					m.issueEpicTi.SetValue(selectedItem.epic)
 					m.issueDescriptionTa.SetValue(selectedItem.description)
 				}
 			}
@@ -343,6 +474,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.focusedView == issueListView {
 				m.issueList.RemoveItem(m.issueList.Index())
 			}
+		case key.Matches(msg, m.keys.Epic):
+			m.applyEpic()
+		case key.Matches(msg, m.keys.Split):
+			cmds = append(cmds, m.split())
+		case key.Matches(msg, m.keys.Submit):
+			m.saveEditor()
+			m.submitted = true
+			m.quitting = true
+			return m, tea.Quit
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 		case key.Matches(msg, m.keys.Quit):
@@ -361,16 +501,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	newSummaryInputModel, newSummaryInputCmd := m.issueSummaryTi.Update(msg)
 	m.issueSummaryTi = newSummaryInputModel
 
+	newEpicInputModel, newEpicInputCmd := m.issueEpicTi.Update(msg)
+	m.issueEpicTi = newEpicInputModel
+
 	newDescriptionInputModel, newDescriptionInputCmd := m.issueDescriptionTa.Update(msg)
 	m.issueDescriptionTa = newDescriptionInputModel
 
-	cmds = append(cmds, newSummaryInputCmd, newDescriptionInputCmd)
+	cmds = append(cmds, newSummaryInputCmd, newEpicInputCmd, newDescriptionInputCmd)
 	return m, tea.Batch(cmds...)
 }
 
 func (m model) View() string {
 	//helpView := m.help.View(m.keys)
 
+	if m.submitted {
+		return "Exporting selected issues...\n"
+	}
+
 	if m.quitting {
 		return "Bye!\n"
 	}
@@ -380,13 +527,24 @@ func (m model) View() string {
 		return "Unable to get main row"
 	}
 
-	repositoryString := repoNameStyle.Render(fmt.Sprintf("Repository: %s", m.issueRepoName))
-	issueEditorCellView := fmt.Sprintf("%s\n%s\n\n\n Description:\n%s", repositoryString, m.issueSummaryTi.View(), m.issueDescriptionTa.View())
+	creditedTo := m.issueUser
+	if m.issueAttribution != "" {
+		creditedTo = fmt.Sprintf("%s (%s)", m.issueUser, m.issueAttribution)
+	}
+	header := fmt.Sprintf("%s by %s in repository: %s", kindLabels[m.issueKind], creditedTo, m.issueRepoName)
+	if m.issueFolded > 0 {
+		header = fmt.Sprintf("%s, folded with %s (%s to split)", header, foldedItems(m.issueKind, m.issueFolded), m.keys.Split.Help().Key)
+	}
+	if m.issueMetrics != "" {
+		header = fmt.Sprintf("%s\n%s", header, m.issueMetrics)
+	}
+	repositoryString := repoNameStyle.Render(header)
+	issueEditorCellView := fmt.Sprintf("%s\n%s\n%s\n\n\n Description:\n%s", repositoryString, m.issueSummaryTi.View(), m.issueEpicTi.View(), m.issueDescriptionTa.View())
 	switch m.focusedView {
 	case issueListView:
 		mainFlexBoxRow.Cell(issueListCell).SetStyle(focusedModelStyle).SetContent(docStyle.Render(m.issueList.View()))
 		mainFlexBoxRow.Cell(issueEditorCell).SetStyle(modelStyle).SetContent(issueEditorCellView)
-	case issueSummaryInputView, issueDescriptionInputView:
+	case issueSummaryInputView, issueEpicInputView, issueDescriptionInputView:
 		mainFlexBoxRow.Cell(issueListCell).SetStyle(modelStyle).SetContent(docStyle.Render(m.issueList.View()))
 		mainFlexBoxRow.Cell(issueEditorCell).SetStyle(focusedModelStyle).SetContent(issueEditorCellView)
 	}
@@ -396,30 +554,169 @@ func (m model) View() string {
 
 func (m *model) nextView() {
 	currentView := m.focusedView
-	m.focusedView = (currentView + 1) % 3
+	m.focusedView = (currentView + 1) % 4
 
 	// if we're not on the issue list view, then ensure the item we were on is updated
 	if !(currentView == issueListView) {
-		selectedItem := m.issueList.Items()[m.issueList.Index()]
-		if selectedItem != nil {
-			issue, ok := selectedItem.(issueItem)
-			if ok {
-				issue.summary = m.issueSummaryTi.Value()
-				issue.description = m.issueDescriptionTa.Value()
-				m.issueList.SetItem(m.issueList.Index(), issue)
-			}
-		}
+		m.saveEditor()
 	}
 
 	switch m.focusedView {
 	case issueListView:
 		m.issueSummaryTi.Blur()
+		m.issueEpicTi.Blur()
 		m.issueDescriptionTa.Blur()
 	case issueSummaryInputView:
 		m.issueSummaryTi.Focus()
+		m.issueEpicTi.Blur()
+		m.issueDescriptionTa.Blur()
+	case issueEpicInputView:
+		m.issueSummaryTi.Blur()
+		m.issueEpicTi.Focus()
 		m.issueDescriptionTa.Blur()
 	case issueDescriptionInputView:
 		m.issueSummaryTi.Blur()
+		m.issueEpicTi.Blur()
 		m.issueDescriptionTa.Focus()
 	}
 }
+
+// saveEditor writes the issue editor values back to the focused list item
+func (m *model) saveEditor() {
+	if m.focusedView == issueListView || len(m.issueList.Items()) == 0 {
+		return
+	}
+
+	selectedItem := m.issueList.Items()[m.issueList.Index()]
+	if selectedItem == nil {
+		return
+	}
+
+	issue, ok := selectedItem.(issueItem)
+	if !ok {
+		return
+	}
+
+	issue.summary = m.issueSummaryTi.Value()
+	issue.epic = m.issueEpicTi.Value()
+	issue.description = m.issueDescriptionTa.Value()
+	m.issueList.SetItem(m.issueList.Index(), issue)
+}
+
+// applyEpic sets the epic from the epic input on every selected list item
+func (m *model) applyEpic() {
+	m.saveEditor()
+	epic := m.issueEpicTi.Value()
+
+	for idx, listItem := range m.issueList.Items() {
+		item, ok := listItem.(issueItem)
+		if !ok || !item.selected {
+			continue
+		}
+
+		item.epic = epic
+		m.issueList.SetItem(idx, item)
+	}
+}
+
+// split turns the issues or PRs folded into the focused list item back into items of their own,
+// placed right after it
+func (m *model) split() tea.Cmd {
+	if m.focusedView != issueListView || len(m.issueList.Items()) == 0 {
+		return nil
+	}
+
+	idx := m.issueList.Index()
+	item, ok := m.issueList.Items()[idx].(issueItem)
+	if !ok || item.folded == 0 {
+		return nil
+	}
+
+	var parts []issueItem
+
+	switch item.kind {
+	case kindMergedPr:
+		pr := m.credit.MergedPrs[item.id]
+		for _, issue := range pr.FoldedIssues {
+			m.credit.Issues[issue.Id] = issue
+			parts = append(parts, newIssueItem(issue))
+		}
+		pr.FoldedIssues = nil
+		m.credit.MergedPrs[item.id] = pr
+	case kindIssue:
+		issue := m.credit.Issues[item.id]
+		for _, pr := range issue.FoldedPrs {
+			m.credit.MergedPrs[pr.Id] = pr
+			parts = append(parts, newMergedPrItem(pr))
+		}
+		issue.FoldedPrs = nil
+		m.credit.Issues[item.id] = issue
+	}
+
+	item.folded = 0
+	m.issueFolded = 0
+	m.issueList.SetItem(idx, item)
+
+	var cmds []tea.Cmd
+	for i, part := range parts {
+		cmds = append(cmds, m.issueList.InsertItem(idx+1+i, part))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// foldedItems counts the items folded into an item of kind, e.g. "2 closed issues"
+func foldedItems(kind string, count int) string {
+	if kind == kindIssue {
+		return text.Plural(count, "closed PR", "closed PRs")
+	}
+	return text.Plural(count, "closed issue", "closed issues")
+}
+
+// Selection returns the credit marked as selected in the final
+// model returned by tea.Program.Run, with any edits from the issue editor applied.
+// ok is false when the program exited without the selection being submitted.
+func Selection(finalModel tea.Model) (selected types.Credit, ok bool) {
+	m, ok := finalModel.(model)
+	if !ok || !m.submitted {
+		return types.Credit{}, false
+	}
+
+	selected = types.NewCredit()
+
+	for _, listItem := range m.issueList.Items() {
+		item, ok := listItem.(issueItem)
+		if !ok || !item.selected {
+			continue
+		}
+
+		switch item.kind {
+		case kindMergedPr:
+			pr := m.credit.MergedPrs[item.id]
+			pr.Title = item.summary
+			pr.Body = item.description
+			pr.Epic = item.epic
+			selected.MergedPrs[item.id] = pr
+		case kindIssue:
+			issue := m.credit.Issues[item.id]
+			issue.Title = item.summary
+			issue.Body = item.description
+			issue.Epic = item.epic
+			selected.Issues[item.id] = issue
+		case kindReview:
+			review := m.credit.Reviews[item.id]
+			review.Title = item.summary
+			review.Body = item.description
+			review.Epic = item.epic
+			selected.Reviews[item.id] = review
+		default:
+			contribution := m.credit.Contributions[item.id]
+			contribution.Title = item.summary
+			contribution.Body = item.description
+			contribution.Epic = item.epic
+			selected.Contributions[item.id] = contribution
+		}
+	}
+
+	return selected, true
+}
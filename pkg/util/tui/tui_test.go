@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ffalor/credit/pkg/util/types"
+)
+
+var (
+	enter  = tea.KeyMsg{Type: tea.KeyEnter}
+	down   = tea.KeyMsg{Type: tea.KeyDown}
+	up     = tea.KeyMsg{Type: tea.KeyUp}
+	tab    = tea.KeyMsg{Type: tea.KeyTab}
+	remove = tea.KeyMsg{Type: tea.KeyCtrlD}
+	epic   = tea.KeyMsg{Type: tea.KeyCtrlG}
+	split  = tea.KeyMsg{Type: tea.KeyCtrlX}
+	submit = tea.KeyMsg{Type: tea.KeyCtrlS}
+	quit   = tea.KeyMsg{Type: tea.KeyCtrlC}
+)
+
+// typed returns the key message for typing s into the focused input
+func typed(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// testCredit is listed as PR "A", PR "B", issue "C", review "D" and commits "E"
+func testCredit() types.Credit {
+	credit := types.NewCredit()
+	credit.MergedPrs["PR_B"] = types.MergedPr{Id: "PR_B", RepoName: "o/r", Title: "B"}
+	credit.MergedPrs["PR_A"] = types.MergedPr{Id: "PR_A", RepoName: "o/r", Title: "A"}
+	credit.Issues["I_C"] = types.Issue{Id: "I_C", RepoName: "o/r", Title: "C"}
+	credit.Reviews["PRR_D"] = types.Review{Id: "PRR_D", RepoName: "o/r", Title: "D"}
+	credit.Contributions["commits:E"] = types.Contribution{Id: "commits:E", Kind: types.ContributionCommits, RepoName: "o/r", Title: "E"}
+	return credit
+}
+
+// foldedCredit holds PR "A" folded with the issues it closed and issue "C" folded with the PR
+// that closed it
+func foldedCredit() types.Credit {
+	credit := types.NewCredit()
+	credit.MergedPrs["PR_A"] = types.MergedPr{
+		Id:       "PR_A",
+		RepoName: "o/r",
+		Title:    "A",
+		FoldedIssues: []types.Issue{
+			{Id: "I_1", RepoName: "o/r", Title: "A1"},
+			{Id: "I_2", RepoName: "o/r", Title: "A2"},
+		},
+	}
+	credit.Issues["I_C"] = types.Issue{
+		Id:        "I_C",
+		RepoName:  "o/r",
+		Title:     "C",
+		FoldedPrs: []types.MergedPr{{Id: "PR_3", RepoName: "o/r", Title: "C1"}},
+	}
+	return credit
+}
+
+func TestSelection(t *testing.T) {
+	tests := []struct {
+		name   string
+		credit types.Credit
+		keys   []tea.KeyMsg
+		want   map[string]string
+		wantOk bool
+	}{
+		{
+			name:   "nothing selected",
+			credit: testCredit(),
+			keys:   []tea.KeyMsg{submit},
+			want:   map[string]string{},
+			wantOk: true,
+		},
+		{
+			name:   "quit",
+			credit: testCredit(),
+			keys:   []tea.KeyMsg{enter, quit},
+			wantOk: false,
+		},
+		{
+			name:   "one of each kind",
+			credit: testCredit(),
+			keys:   []tea.KeyMsg{enter, down, down, enter, down, enter, down, enter, submit},
+			want:   map[string]string{"PR_A": "A", "I_C": "C", "PRR_D": "D", "commits:E": "E"},
+			wantOk: true,
+		},
+		{
+			name:   "toggled twice",
+			credit: testCredit(),
+			keys:   []tea.KeyMsg{enter, down, enter, up, enter, submit},
+			want:   map[string]string{"PR_B": "B"},
+			wantOk: true,
+		},
+		{
+			name:   "deleted",
+			credit: testCredit(),
+			keys:   []tea.KeyMsg{enter, remove, enter, submit},
+			want:   map[string]string{"PR_B": "B"},
+			wantOk: true,
+		},
+		{
+			name:   "edited summary",
+			credit: testCredit(),
+			keys:   []tea.KeyMsg{down, enter, tab, typed(" edited"), submit},
+			want:   map[string]string{"PR_B": "B edited"},
+			wantOk: true,
+		},
+		{
+			name:   "folded",
+			credit: foldedCredit(),
+			keys:   []tea.KeyMsg{enter, down, enter, submit},
+			want:   map[string]string{"PR_A": "A", "I_C": "C"},
+			wantOk: true,
+		},
+		{
+			name:   "split PR",
+			credit: foldedCredit(),
+			keys:   []tea.KeyMsg{split, down, enter, down, enter, submit},
+			want:   map[string]string{"I_1": "A1", "I_2": "A2"},
+			wantOk: true,
+		},
+		{
+			name:   "split issue",
+			credit: foldedCredit(),
+			keys:   []tea.KeyMsg{down, split, enter, down, enter, submit},
+			want:   map[string]string{"I_C": "C", "PR_3": "C1"},
+			wantOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, ok := Selection(run(t, tt.credit, tt.keys...))
+			if ok != tt.wantOk {
+				t.Fatalf("Selection() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+
+			if got := titles(selected); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Selection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectionSplitUnfolds(t *testing.T) {
+	selected, ok := Selection(run(t, foldedCredit(), enter, split, down, enter, submit))
+	if !ok {
+		t.Fatal("Selection() ok = false")
+	}
+
+	pr, ok := selected.MergedPrs["PR_A"]
+	if !ok {
+		t.Fatalf("Selection() is missing PR_A, got %v", titles(selected))
+	}
+	if len(pr.FoldedIssues) != 0 {
+		t.Errorf("PR_A still has folded issues %v after split", pr.FoldedIssues)
+	}
+	if _, ok := selected.Issues["I_1"]; !ok {
+		t.Errorf("Selection() is missing split issue I_1, got %v", titles(selected))
+	}
+}
+
+func TestSelectionEpic(t *testing.T) {
+	keys := []tea.KeyMsg{enter, down, down, enter, tab, tab, typed("EPIC-1"), epic, submit}
+	selected, ok := Selection(run(t, testCredit(), keys...))
+	if !ok {
+		t.Fatal("Selection() ok = false")
+	}
+
+	var epics []string
+	for _, pr := range selected.MergedPrs {
+		epics = append(epics, pr.Id+"="+pr.Epic)
+	}
+	for _, issue := range selected.Issues {
+		epics = append(epics, issue.Id+"="+issue.Epic)
+	}
+	sort.Strings(epics)
+
+	want := []string{"I_C=EPIC-1", "PR_A=EPIC-1"}
+	if !reflect.DeepEqual(epics, want) {
+		t.Errorf("epics = %v, want %v", epics, want)
+	}
+}
+
+// run drives a model for credit with keys and returns the final model
+func run(t *testing.T, credit types.Credit, keys ...tea.KeyMsg) tea.Model {
+	t.Helper()
+
+	initial, err := InitialModel(credit)
+	if err != nil {
+		t.Fatalf("InitialModel() error = %v", err)
+	}
+
+	var m tea.Model = initial
+	for _, k := range keys {
+		m, _ = m.Update(k)
+	}
+	return m
+}
+
+// titles maps the id of every selected item to its title
+func titles(credit types.Credit) map[string]string {
+	got := map[string]string{}
+	for id, pr := range credit.MergedPrs {
+		got[id] = pr.Title
+	}
+	for id, issue := range credit.Issues {
+		got[id] = issue.Title
+	}
+	for id, review := range credit.Reviews {
+		got[id] = review.Title
+	}
+	for id, contribution := range credit.Contributions {
+		got[id] = contribution.Title
+	}
+	return got
+}
@@ -1,5 +1,7 @@
 package types
 
+import "sort"
+
 type Issue struct {
 	Id       string
 	RepoName string
@@ -7,7 +9,27 @@ type Issue struct {
 	Title    string
 	Url      string
 	Epic     string
+	ClosedAt string
 	Labels   []string
+	// StateReason is why the issue was closed, COMPLETED or NOT_PLANNED
+	StateReason string
+	Milestone   string
+	Assignees   []string
+	// ClosedBy are the PRs that closed the issue
+	ClosedBy []PullRequestRef
+	// Attribution is the strategy the issue is credited to User by e.g. author or assignee
+	Attribution string
+	// FoldedPrs are the PRs closing the issue that were folded into it by FoldIntoIssue
+	FoldedPrs []MergedPr
+	// User is the login the issue is credited to
+	User string
+}
+
+// PullRequestRef refers to a pull request
+type PullRequestRef struct {
+	Id    string
+	Title string
+	Url   string
 }
 
 type MergedPr struct {
@@ -19,66 +41,624 @@ type MergedPr struct {
 	CreatedAt string
 	Epic      string
 	MergedAt  string
+	// ClosingIssueIds are the ids of the issues closed by the PR
+	ClosingIssueIds []string
+	// FoldedIssues are the issues closed by the PR that were folded into it by FoldIntoPr
+	FoldedIssues []Issue
+	Metrics      PrMetrics
+	// User is the login the PR is credited to
+	User string
 }
 
-type MergedPrQuery struct {
-	Search struct {
-		PageInfo struct {
-			EndCursor   string
-			HasNextPage bool
+// PrMetrics is the size of a PR and the activity on it
+type PrMetrics struct {
+	Additions    int
+	Deletions    int
+	ChangedFiles int
+	Commits      int
+	Reviews      int
+	Comments     int
+	// Fetched is set when the metrics were fetched with the PR, they are all zero otherwise
+	Fetched bool
+}
+
+// Metrics PrMetrics.Value accepts
+const (
+	// MetricLines is the number of added and deleted lines
+	MetricLines        = "lines"
+	MetricChangedFiles = "changedFiles"
+	MetricCommits      = "commits"
+)
+
+// Metrics lists the names accepted by PrMetrics.Value
+var Metrics = []string{MetricLines, MetricChangedFiles, MetricCommits}
+
+// Value returns the named metric, see Metrics
+func (m PrMetrics) Value(name string) int {
+	switch name {
+	case MetricChangedFiles:
+		return m.ChangedFiles
+	case MetricCommits:
+		return m.Commits
+	default:
+		return m.Additions + m.Deletions
+	}
+}
+
+// Review is a review given on someone else's PR
+type Review struct {
+	Id       string
+	RepoName string
+	// Title and Body describe the credit item, they default to the PR title and review body
+	Title       string
+	Body        string
+	Url         string
+	Epic        string
+	State       string
+	SubmittedAt string
+	PrId        string
+	PrTitle     string
+	PrUrl       string
+	// User is the login that gave the review
+	User string
+}
+
+// Contribution kinds
+const (
+	// ContributionCommits is the commits pushed to a repository on one day
+	ContributionCommits = "commits"
+	// ContributionRepository is a repository created by the user
+	ContributionRepository = "repository"
+)
+
+// Contribution is activity other than PRs, issues and reviews, such as commits or created repositories
+type Contribution struct {
+	Id string
+	// Kind is one of the Contribution kinds
+	Kind       string
+	RepoName   string
+	Title      string
+	Body       string
+	Url        string
+	Epic       string
+	OccurredAt string
+	// User is the login the contribution is credited to
+	User string
+}
+
+// Credit is all work credited to a user, keyed by github node id
+type Credit struct {
+	MergedPrs     map[string]MergedPr
+	Issues        map[string]Issue
+	Reviews       map[string]Review
+	Contributions map[string]Contribution
+}
+
+// NewCredit returns an empty Credit
+func NewCredit() Credit {
+	return Credit{
+		MergedPrs:     make(map[string]MergedPr),
+		Issues:        make(map[string]Issue),
+		Reviews:       make(map[string]Review),
+		Contributions: make(map[string]Contribution),
+	}
+}
+
+// Merge adds all items of other to c
+func (c Credit) Merge(other Credit) {
+	for id, pr := range other.MergedPrs {
+		c.MergedPrs[id] = pr
+	}
+	for id, issue := range other.Issues {
+		c.Issues[id] = issue
+	}
+	for id, review := range other.Reviews {
+		c.Reviews[id] = review
+	}
+	for id, contribution := range other.Contributions {
+		c.Contributions[id] = contribution
+	}
+}
+
+// Assign credits every item of c to user
+func (c Credit) Assign(user string) {
+	for id, pr := range c.MergedPrs {
+		pr.User = user
+		c.MergedPrs[id] = pr
+	}
+	for id, issue := range c.Issues {
+		issue.User = user
+		c.Issues[id] = issue
+	}
+	for id, review := range c.Reviews {
+		review.User = user
+		c.Reviews[id] = review
+	}
+	for id, contribution := range c.Contributions {
+		contribution.User = user
+		c.Contributions[id] = contribution
+	}
+}
+
+// Users returns the sorted logins the items of c are credited to
+func (c Credit) Users() []string {
+	seen := make(map[string]bool)
+	var users []string
+	add := func(user string) {
+		if user != "" && !seen[user] {
+			seen[user] = true
+			users = append(users, user)
 		}
-		Edges []struct {
+	}
+
+	for _, pr := range c.MergedPrs {
+		add(pr.User)
+	}
+	for _, issue := range c.Issues {
+		add(issue.User)
+	}
+	for _, review := range c.Reviews {
+		add(review.User)
+	}
+	for _, contribution := range c.Contributions {
+		add(contribution.User)
+	}
+
+	sort.Strings(users)
+	return users
+}
+
+// Credited returns the user an item is credited to, itemUser when it is set and user, the user
+// of the export, otherwise
+func Credited(itemUser string, user string) string {
+	if itemUser != "" {
+		return itemUser
+	}
+	return user
+}
+
+// Fold modes combining a merged PR and the issues it closed into a single item
+const (
+	// FoldIntoPr folds the closed issues into their PR
+	FoldIntoPr = "pr"
+	// FoldIntoIssue folds each PR into the first issue it closed
+	FoldIntoIssue = "issue"
+)
+
+// FoldModes lists the valid modes of Credit.Fold
+var FoldModes = []string{FoldIntoPr, FoldIntoIssue}
+
+// Fold combines every merged PR with the issues it closed that are credited to the same user
+// into a single item according to mode, removing the folded items from c. An empty mode leaves c
+// as is.
+func (c Credit) Fold(mode string) {
+	if mode == "" {
+		return
+	}
+
+	ids := make([]string, 0, len(c.MergedPrs))
+	for id := range c.MergedPrs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		pr := c.MergedPrs[id]
+
+		for _, issueId := range pr.ClosingIssueIds {
+			issue, ok := c.Issues[issueId]
+			if !ok || issue.User != pr.User {
+				continue
+			}
+
+			if mode == FoldIntoIssue {
+				issue.FoldedPrs = append(issue.FoldedPrs, pr)
+				c.Issues[issueId] = issue
+				delete(c.MergedPrs, id)
+				break
+			}
+
+			pr.FoldedIssues = append(pr.FoldedIssues, issue)
+			c.MergedPrs[id] = pr
+			delete(c.Issues, issueId)
+		}
+	}
+}
+
+// Len returns the number of credited items
+func (c Credit) Len() int {
+	return len(c.MergedPrs) + len(c.Issues) + len(c.Reviews) + len(c.Contributions)
+}
+
+// PageInfo is the pagination state of a connection
+type PageInfo struct {
+	EndCursor   string
+	HasNextPage bool
+}
+
+// RateLimit is the graphql rate limit budget reported alongside a query
+type RateLimit struct {
+	Remaining int
+	ResetAt   string
+	Cost      int
+}
+
+// SearchQuery is a query over a paginated github search connection
+type SearchQuery interface {
+	// Page returns the pagination state and total number of results of the search
+	Page() (pageInfo PageInfo, issueCount int)
+	// Results returns the number of results in the page
+	Results() int
+	// Rate returns the rate limit budget after the query
+	Rate() RateLimit
+}
+
+// PullRequestNode is a pull request along with the issues it closes and its size
+type PullRequestNode struct {
+	Id           string
+	Title        string
+	Body         string
+	CreatedAt    string
+	Merged       bool
+	MergedAt     string
+	Url          string
+	Additions    int
+	Deletions    int
+	ChangedFiles int
+	Commits      struct {
+		TotalCount int
+	}
+	Reviews struct {
+		TotalCount int
+	}
+	Comments struct {
+		TotalCount int
+	}
+	ClosingIssuesReferences struct {
+		Nodes []IssueNode
+	} `graphql:"closingIssuesReferences(first: 100)"`
+	BaseRepository RepositoryNode `graphql:"baseRepository"`
+}
+
+// RepositoryNode is the repository of a pull request or issue
+type RepositoryNode struct {
+	Name          string
+	NameWithOwner string
+	IsPrivate     bool
+}
+
+// IssueNode is an issue
+type IssueNode struct {
+	Id          string
+	Title       string
+	Body        string
+	Url         string
+	Closed      bool
+	ClosedAt    string
+	StateReason string
+	Author      struct {
+		Login string
+	}
+	Milestone struct {
+		Title string
+	}
+	Labels struct {
+		Nodes []struct {
+			Name string
+		}
+	} `graphql:"labels(first: 10)"`
+	Assignees struct {
+		Nodes []struct {
+			Login string
+		}
+	} `graphql:"assignees(first: 10)"`
+	// TimelineItems holds the event that last closed the issue
+	TimelineItems struct {
+		Nodes []struct {
+			ClosedEvent struct {
+				Actor struct {
+					Login string
+				}
+				Closer struct {
+					PullRequest struct {
+						Id    string
+						Title string
+						Url   string
+					} `graphql:"... on PullRequest"`
+				}
+			} `graphql:"... on ClosedEvent"`
+		}
+	} `graphql:"timelineItems(itemTypes: [CLOSED_EVENT], last: 1)"`
+	Repository RepositoryNode `graphql:"repository"`
+}
+
+type MergedPrQuery struct {
+	RateLimit RateLimit
+	Search    struct {
+		IssueCount int
+		PageInfo   PageInfo
+		Edges      []struct {
 			Node struct {
-				PullRequest struct {
-					Id                      string
-					Title                   string
-					Body                    string
-					CreatedAt               string
-					MergedAt                string
-					Url                     string
-					ClosingIssuesReferences struct {
-						Nodes []struct {
-							Id     string
-							Body   string
-							Title  string
-							Url    string
-							Labels struct {
-								Nodes []struct {
-									Name string
-								}
-							} `graphql:"labels(first: 10)"`
-						}
-					} `graphql:"closingIssuesReferences(first: 100)"`
-					BaseRepository struct {
-						Name string
-					} `graphql:"baseRepository"`
-				} `graphql:"... on PullRequest"`
+				PullRequest PullRequestNode `graphql:"... on PullRequest"`
 			}
 		}
 	} `graphql:"search(query: $query, type: ISSUE, first: 100, after: $searchCursor)"`
 }
 
 type IssueQuery struct {
-	Search struct {
-		PageInfo struct {
-			EndCursor   string
-			HasNextPage bool
+	RateLimit RateLimit
+	Search    struct {
+		IssueCount int
+		PageInfo   PageInfo
+		Nodes      []struct {
+			Issue IssueNode `graphql:"... on Issue"`
 		}
-		Nodes []struct {
-			Issue struct {
-				Id     string
-				Title  string
-				Body   string
-				Url    string
-				Labels struct {
-					Nodes []struct {
-						Name string
+	} `graphql:"search(query: $query, type: ISSUE, first: 100, after: $searchCursor)"`
+}
+
+func (q *MergedPrQuery) Page() (PageInfo, int) {
+	return q.Search.PageInfo, q.Search.IssueCount
+}
+
+func (q *IssueQuery) Page() (PageInfo, int) {
+	return q.Search.PageInfo, q.Search.IssueCount
+}
+
+func (q *MergedPrQuery) Results() int {
+	return len(q.Search.Edges)
+}
+
+func (q *MergedPrQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+func (q *IssueQuery) Results() int {
+	return len(q.Search.Nodes)
+}
+
+func (q *IssueQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+type ReviewedPrQuery struct {
+	RateLimit RateLimit
+	Search    struct {
+		IssueCount int
+		PageInfo   PageInfo
+		Nodes      []struct {
+			PullRequest struct {
+				Id             string
+				Title          string
+				Url            string
+				BaseRepository RepositoryNode `graphql:"baseRepository"`
+				Reviews        struct {
+					Nodes []ReviewNode
+				} `graphql:"reviews(last: 50)"`
+			} `graphql:"... on PullRequest"`
+		}
+	} `graphql:"search(query: $query, type: ISSUE, first: 100, after: $searchCursor)"`
+}
+
+// ReviewNode is a pull request review
+type ReviewNode struct {
+	Id          string
+	State       string
+	SubmittedAt string
+	Url         string
+	Body        string
+	Author      struct {
+		Login string
+	}
+}
+
+type ReviewContributionsQuery struct {
+	RateLimit RateLimit
+	User      struct {
+		ContributionsCollection struct {
+			PullRequestReviewContributions struct {
+				PageInfo PageInfo
+				Nodes    []struct {
+					PullRequestReview ReviewNode
+					PullRequest       struct {
+						Id             string
+						Title          string
+						Url            string
+						BaseRepository RepositoryNode `graphql:"baseRepository"`
 					}
-				} `graphql:"labels(first: 10)"`
+				}
+			} `graphql:"pullRequestReviewContributions(first: 100, after: $cursor)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $user)"`
+}
+
+func (q *ReviewedPrQuery) Page() (PageInfo, int) {
+	return q.Search.PageInfo, q.Search.IssueCount
+}
+
+func (q *ReviewedPrQuery) Results() int {
+	return len(q.Search.Nodes)
+}
+
+func (q *ReviewedPrQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+// ContributionsQuery is a query over a paginated connection of a user's contributionsCollection
+type ContributionsQuery interface {
+	// Page returns the pagination state of the contributions
+	Page() PageInfo
+	// Rate returns the rate limit budget after the query
+	Rate() RateLimit
+}
+
+type PrContributionsQuery struct {
+	RateLimit RateLimit
+	User      struct {
+		ContributionsCollection struct {
+			PullRequestContributions struct {
+				PageInfo PageInfo
+				Nodes    []struct {
+					PullRequest PullRequestNode
+				}
+			} `graphql:"pullRequestContributions(first: 100, after: $cursor)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $user)"`
+}
+
+type IssueContributionsQuery struct {
+	RateLimit RateLimit
+	User      struct {
+		ContributionsCollection struct {
+			IssueContributions struct {
+				PageInfo PageInfo
+				Nodes    []struct {
+					Issue IssueNode
+				}
+			} `graphql:"issueContributions(first: 100, after: $cursor)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $user)"`
+}
+
+type RepositoryContributionsQuery struct {
+	RateLimit RateLimit
+	User      struct {
+		ContributionsCollection struct {
+			RepositoryContributions struct {
+				PageInfo PageInfo
+				Nodes    []struct {
+					OccurredAt string
+					Repository struct {
+						Id            string
+						Name          string
+						NameWithOwner string
+						Url           string
+						Description   string
+						IsPrivate     bool
+					}
+				}
+			} `graphql:"repositoryContributions(first: 100, after: $cursor)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $user)"`
+}
+
+// CommitContributionsQuery returns the number of commits per repository and day. It is not
+// paginated, a window may span at most 100 days with commits per repository.
+type CommitContributionsQuery struct {
+	RateLimit RateLimit
+	User      struct {
+		ContributionsCollection struct {
+			CommitContributionsByRepository []struct {
 				Repository struct {
-					Name string
-				} `graphql:"repository"`
-			} `graphql:"... on Issue"`
+					Name          string
+					NameWithOwner string
+					Url           string
+					IsPrivate     bool
+				}
+				Contributions struct {
+					Nodes []struct {
+						OccurredAt  string
+						CommitCount int
+					}
+				} `graphql:"contributions(first: 100)"`
+			} `graphql:"commitContributionsByRepository(maxRepositories: 100)"`
+		} `graphql:"contributionsCollection(from: $from, to: $to)"`
+	} `graphql:"user(login: $user)"`
+}
+
+// UserIdQuery returns the node id of a user
+type UserIdQuery struct {
+	RateLimit RateLimit
+	User      struct {
+		Id string
+	} `graphql:"user(login: $user)"`
+}
+
+// OwnerRepositoriesQuery lists the repositories owned by a user or organization
+type OwnerRepositoriesQuery struct {
+	RateLimit       RateLimit
+	RepositoryOwner struct {
+		Repositories struct {
+			PageInfo PageInfo
+			Nodes    []RepositoryNode
+		} `graphql:"repositories(first: 100, after: $cursor)"`
+	} `graphql:"repositoryOwner(login: $owner)"`
+}
+
+// CommitHistoryQuery returns the commits by an author on the default branch of a repository
+// along with the PRs each commit belongs to
+type CommitHistoryQuery struct {
+	RateLimit  RateLimit
+	Repository struct {
+		Name             string
+		NameWithOwner    string
+		Url              string
+		IsPrivate        bool
+		DefaultBranchRef struct {
+			Name   string
+			Target struct {
+				Commit struct {
+					History struct {
+						PageInfo PageInfo
+						Nodes    []struct {
+							Oid                    string
+							MessageHeadline        string
+							CommittedDate          string
+							AssociatedPullRequests struct {
+								Nodes []struct {
+									Merged bool
+								}
+							} `graphql:"associatedPullRequests(first: 5)"`
+						}
+					} `graphql:"history(first: 100, after: $cursor, author: $author, since: $since, until: $until)"`
+				} `graphql:"... on Commit"`
+			}
 		}
-	} `graphql:"search(query: $query, type: ISSUE, first: 100, after: $searchCursor)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+func (q *ReviewContributionsQuery) Page() PageInfo {
+	return q.User.ContributionsCollection.PullRequestReviewContributions.PageInfo
+}
+
+func (q *ReviewContributionsQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+func (q *PrContributionsQuery) Page() PageInfo {
+	return q.User.ContributionsCollection.PullRequestContributions.PageInfo
+}
+
+func (q *PrContributionsQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+func (q *IssueContributionsQuery) Page() PageInfo {
+	return q.User.ContributionsCollection.IssueContributions.PageInfo
+}
+
+func (q *IssueContributionsQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+func (q *RepositoryContributionsQuery) Page() PageInfo {
+	return q.User.ContributionsCollection.RepositoryContributions.PageInfo
+}
+
+func (q *RepositoryContributionsQuery) Rate() RateLimit {
+	return q.RateLimit
+}
+
+type TeamMembersQuery struct {
+	RateLimit    RateLimit
+	Organization struct {
+		Team struct {
+			Id      string
+			Members struct {
+				PageInfo PageInfo
+				Nodes    []struct {
+					Login string
+				}
+			} `graphql:"members(first: 100, after: $cursor)"`
+		} `graphql:"team(slug: $team)"`
+	} `graphql:"organization(login: $org)"`
 }
@@ -0,0 +1,155 @@
+package types
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func ids(credit Credit) (prs []string, issues []string) {
+	for id := range credit.MergedPrs {
+		prs = append(prs, id)
+	}
+	for id := range credit.Issues {
+		issues = append(issues, id)
+	}
+	sort.Strings(prs)
+	sort.Strings(issues)
+	return prs, issues
+}
+
+func TestMerge(t *testing.T) {
+	credit := NewCredit()
+	credit.MergedPrs["pr1"] = MergedPr{Id: "pr1", Title: "old", User: "octocat"}
+	credit.Issues["issue1"] = Issue{Id: "issue1", User: "octocat"}
+
+	other := NewCredit()
+	other.MergedPrs["pr1"] = MergedPr{Id: "pr1", Title: "new", User: "hubot"}
+	other.MergedPrs["pr2"] = MergedPr{Id: "pr2", User: "hubot"}
+	other.Reviews["review1"] = Review{Id: "review1", User: "hubot"}
+	other.Contributions["commits:hubot:o/r"] = Contribution{Id: "commits:hubot:o/r", User: "hubot"}
+
+	credit.Merge(other)
+
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{name: "merged prs", got: len(credit.MergedPrs), want: 2},
+		{name: "issues", got: len(credit.Issues), want: 1},
+		{name: "reviews", got: len(credit.Reviews), want: 1},
+		{name: "contributions", got: len(credit.Contributions), want: 1},
+		{name: "total", got: credit.Len(), want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %d, want %d", tt.got, tt.want)
+			}
+		})
+	}
+
+	// items of other replace the ones with the same id
+	if got := credit.MergedPrs["pr1"].Title; got != "new" {
+		t.Errorf("MergedPrs[pr1].Title = %q, want %q", got, "new")
+	}
+	if got, want := credit.Users(), []string{"hubot", "octocat"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Users() = %v, want %v", got, want)
+	}
+}
+
+func TestFold(t *testing.T) {
+	newCredit := func() Credit {
+		credit := NewCredit()
+		credit.MergedPrs["pr1"] = MergedPr{Id: "pr1", User: "octocat", ClosingIssueIds: []string{"issue1", "issue2"}}
+		credit.MergedPrs["pr2"] = MergedPr{Id: "pr2", User: "octocat", ClosingIssueIds: []string{"issue1"}}
+		// closes an issue credited to someone else
+		credit.MergedPrs["pr3"] = MergedPr{Id: "pr3", User: "octocat", ClosingIssueIds: []string{"issue3"}}
+		// closes an issue that is not credited
+		credit.MergedPrs["pr4"] = MergedPr{Id: "pr4", User: "octocat", ClosingIssueIds: []string{"missing"}}
+		credit.Issues["issue1"] = Issue{Id: "issue1", User: "octocat"}
+		credit.Issues["issue2"] = Issue{Id: "issue2", User: "octocat"}
+		credit.Issues["issue3"] = Issue{Id: "issue3", User: "hubot"}
+		return credit
+	}
+
+	tests := []struct {
+		name       string
+		mode       string
+		wantPrs    []string
+		wantIssues []string
+		// wantFolded maps the remaining items to the ids folded into them
+		wantFolded map[string][]string
+	}{
+		{
+			name:       "no folding",
+			mode:       "",
+			wantPrs:    []string{"pr1", "pr2", "pr3", "pr4"},
+			wantIssues: []string{"issue1", "issue2", "issue3"},
+			wantFolded: map[string][]string{},
+		},
+		{
+			name:       "into pr",
+			mode:       FoldIntoPr,
+			wantPrs:    []string{"pr1", "pr2", "pr3", "pr4"},
+			wantIssues: []string{"issue3"},
+			// issue1 is folded into the first PR closing it only
+			wantFolded: map[string][]string{"pr1": {"issue1", "issue2"}},
+		},
+		{
+			name:       "into issue",
+			mode:       FoldIntoIssue,
+			wantPrs:    []string{"pr3", "pr4"},
+			wantIssues: []string{"issue1", "issue2", "issue3"},
+			// a PR is folded into the first issue it closed
+			wantFolded: map[string][]string{"issue1": {"pr1", "pr2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credit := newCredit()
+			credit.Fold(tt.mode)
+
+			prs, issues := ids(credit)
+			if !reflect.DeepEqual(prs, tt.wantPrs) {
+				t.Errorf("merged prs = %v, want %v", prs, tt.wantPrs)
+			}
+			if !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Errorf("issues = %v, want %v", issues, tt.wantIssues)
+			}
+
+			folded := make(map[string][]string)
+			for id, pr := range credit.MergedPrs {
+				for _, issue := range pr.FoldedIssues {
+					folded[id] = append(folded[id], issue.Id)
+				}
+			}
+			for id, issue := range credit.Issues {
+				for _, pr := range issue.FoldedPrs {
+					folded[id] = append(folded[id], pr.Id)
+				}
+			}
+			if !reflect.DeepEqual(folded, tt.wantFolded) {
+				t.Errorf("folded = %v, want %v", folded, tt.wantFolded)
+			}
+		})
+	}
+}
+
+func TestCredited(t *testing.T) {
+	tests := []struct {
+		itemUser string
+		want     string
+	}{
+		{itemUser: "hubot", want: "hubot"},
+		{itemUser: "", want: "octocat"},
+	}
+
+	for _, tt := range tests {
+		if got := Credited(tt.itemUser, "octocat"); got != tt.want {
+			t.Errorf("Credited(%q, %q) = %q, want %q", tt.itemUser, "octocat", got, tt.want)
+		}
+	}
+}